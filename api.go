@@ -3,8 +3,11 @@ package pixela
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -18,23 +21,29 @@ const (
 )
 
 type requestParameter struct {
-	Method string
-	URL    string
-	Header map[string]string
-	Body   []byte
+	// Operation is the name of the method that sends the request, such as "Pixel.Increment".
+	Operation string
+	Method    string
+	URL       string
+	Header    map[string]string
+	Body      []byte
 }
 
 // Result is Pixela API Result struct.
 type Result struct {
 	Message   string `json:"message"`
 	IsSuccess bool   `json:"isSuccess"`
+
+	// StatusCode is the HTTP status code of the response the Result was parsed from, or 0 if it is unknown.
+	StatusCode int `json:"-"`
 }
 
 func newHTTPRequest(param *requestParameter) (*http.Request, error) {
-	req, err := http.NewRequest(
-		param.Method,
-		param.URL,
-		bytes.NewReader(param.Body))
+	var body io.Reader
+	if len(param.Body) > 0 {
+		body = bytes.NewReader(param.Body)
+	}
+	req, err := http.NewRequest(param.Method, param.URL, body)
 	if err != nil {
 		return &http.Request{}, errors.Wrap(err, "failed to create http.Request")
 	}
@@ -48,86 +57,185 @@ func newHTTPRequest(param *requestParameter) (*http.Request, error) {
 	return req, nil
 }
 
-func doRequest(param *requestParameter) ([]byte, error) {
+// do sends the request with the HTTP client of c and passes the response to handle.
+// The errors of do and handle must mask the secrets of the request with param.redact.
+// In the dry-run mode, a mutating request is recorded instead of being sent.
+// If logging is enabled, the request is logged after handle returns.
+func (c *Client) do(param *requestParameter, handle func(resp *http.Response) error) error {
+	if recorded, err := c.recordRequest(param, handle); recorded {
+		return err
+	}
+	if c == nil || c.logger == nil {
+		return c.send(param, handle)
+	}
+
+	// The request path does not retry, so every request is the first attempt.
+	entry := &LogEntry{Operation: param.Operation, Method: param.Method, URL: param.redact(param.URL), Attempt: 1}
+	var body bytes.Buffer
+	start := time.Now()
+	err := c.send(param, func(resp *http.Response) error {
+		entry.StatusCode = resp.StatusCode
+		resp.Body = ioutil.NopCloser(io.TeeReader(resp.Body, &body))
+		return handle(resp)
+	})
+	entry.Latency = time.Since(start)
+	entry.Message = param.redact(pixelaMessage(body.Bytes()))
+	entry.Err = err
+	if c.verbose {
+		entry.RequestBody = param.redact(string(param.Body))
+		entry.ResponseBody = param.redact(body.String())
+	}
+	c.logger.Log(entry)
+	return err
+}
+
+// send sends the request with the HTTP client of c and passes the response to handle.
+// The body is limited to the maximum response size, and is drained and closed after handle returns
+// so that the connection can be reused.
+func (c *Client) send(param *requestParameter, handle func(resp *http.Response) error) error {
 	req, err := newHTTPRequest(param)
 	if err != nil {
-		return []byte{}, errors.Wrap(err, "failed to create http.Request")
+		return errors.Wrap(param.redactError(errors.Cause(err)), "failed to create http.Request")
 	}
 
-	client := http.Client{}
-	resp, err := client.Do(req)
+	t := c.httpTransport()
+	var resp *http.Response
 	if clientMock != nil {
 		resp, err = clientMock.do(req)
+	} else {
+		resp, err = t.client.Do(req)
 	}
 	if err != nil {
-		return []byte{}, errors.Wrapf(err, "failed http.Client do")
+		return errors.Wrapf(param.redactError(err), "failed http.Client do")
 	}
-	defer resp.Body.Close()
+	body := resp.Body
+	defer body.Close()
+	defer io.CopyN(ioutil.Discard, body, maxDrainSize)
 
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return []byte{}, errors.Wrapf(err, "failed to read response body")
+	if resp.Body, err = t.limit(resp); err != nil {
+		return err
 	}
+	return handle(resp)
+}
 
-	return b, nil
+func (c *Client) doRequest(param *requestParameter) ([]byte, error) {
+	b := []byte{}
+	err := c.do(param, func(resp *http.Response) error {
+		var err error
+		b, err = readBody(resp)
+		return err
+	})
+	return b, err
 }
 
-func mustDoRequest(param *requestParameter) ([]byte, error) {
-	req, err := newHTTPRequest(param)
-	if err != nil {
-		return []byte{}, errors.Wrap(err, "failed to create http.Request")
-	}
+func (c *Client) mustDoRequest(param *requestParameter) ([]byte, error) {
+	b := []byte{}
+	err := c.do(param, func(resp *http.Response) error {
+		var err error
+		if b, err = readBody(resp); err != nil {
+			return err
+		}
+		if resp.StatusCode >= 300 {
+			return errors.Errorf("failed to call API: %s", param.redact(string(b)))
+		}
+		return nil
+	})
+	return b, err
+}
 
-	client := http.Client{}
-	resp, err := client.Do(req)
-	if clientMock != nil {
-		resp, err = clientMock.do(req)
-	}
+// doConditionalRequest does the request like mustDoRequest, but it returns the header of the response too
+// and does not fail with 304 Not Modified.
+func (c *Client) doConditionalRequest(param *requestParameter) (int, http.Header, []byte, error) {
+	var statusCode int
+	var header http.Header
+	b := []byte{}
+	err := c.do(param, func(resp *http.Response) error {
+		var err error
+		if b, err = readBody(resp); err != nil {
+			return err
+		}
+		statusCode, header = resp.StatusCode, resp.Header
+		if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotModified {
+			return errors.Errorf("failed to call API: %s", param.redact(string(b)))
+		}
+		return nil
+	})
+	return statusCode, header, b, err
+}
+
+func (c *Client) doRequestAndParseResponse(param *requestParameter) (*Result, error) {
+	var result Result
+	statusCode, err := c.doRequestAndDecode(param, &result)
 	if err != nil {
-		return []byte{}, errors.Wrapf(err, "failed http.Client do")
+		return &Result{}, err
 	}
-	defer resp.Body.Close()
+	result.StatusCode = statusCode
+	return &result, nil
+}
+
+// doRequestAndDecode does the request and decodes the JSON response into v.
+// It returns the status code of the response.
+func (c *Client) doRequestAndDecode(param *requestParameter, v interface{}) (int, error) {
+	var statusCode int
+	err := c.do(param, func(resp *http.Response) error {
+		statusCode = resp.StatusCode
+		return decodeJSON(resp.Body, v, param.redact)
+	})
+	return statusCode, err
+}
 
+func readBody(resp *http.Response) ([]byte, error) {
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return []byte{}, errors.Wrapf(err, "failed to read response body")
 	}
-
-	if resp.StatusCode >= 300 {
-		return b, errors.Errorf("failed to call API: %s", string(b))
-	}
-
 	return b, nil
 }
 
-func doRequestAndParseResponse(param *requestParameter) (*Result, error) {
-	req, err := newHTTPRequest(param)
-	if err != nil {
-		return &Result{}, errors.Wrap(err, "failed to create http.Request")
-	}
+// maxErrorBodySize is the size of the head of a response body reported if the body is not valid JSON.
+const maxErrorBodySize = 512
 
-	client := http.Client{}
-	resp, err := client.Do(req)
-	if clientMock != nil {
-		resp, err = clientMock.do(req)
-	}
-	if err != nil {
-		return &Result{}, errors.Wrapf(err, "failed http.Client do")
-	}
-	defer resp.Body.Close()
+// jsonBody is the body decoded by decodeJSON. It keeps the head of the body and the error of reading it.
+type jsonBody struct {
+	r    io.Reader
+	head []byte
+	err  error
+}
 
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return &Result{}, errors.Wrapf(err, "failed to read response body")
+func (b *jsonBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if rest := cap(b.head) - len(b.head); rest > 0 {
+		if n < rest {
+			rest = n
+		}
+		b.head = append(b.head, p[:rest]...)
 	}
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
 
-	return parseNormalResponse(b)
+// jsonBodyPool holds the jsonBody values of decodeJSON, so that the heads of the bodies are not allocated every time.
+var jsonBodyPool = sync.Pool{
+	New: func() interface{} { return &jsonBody{head: make([]byte, 0, maxErrorBodySize)} },
 }
 
-func parseNormalResponse(b []byte) (*Result, error) {
-	var result Result
-	if err := json.Unmarshal(b, &result); err != nil {
-		return &Result{}, errors.Wrapf(err, "failed to unmarshal json: "+string(b))
-	}
-	return &result, nil
+// decodeJSON decodes the body from r into v as it is read, without buffering the whole body.
+// If the body is not valid JSON, its head is reported masked by redact.
+func decodeJSON(r io.Reader, v interface{}, redact func(string) string) error {
+	body := jsonBodyPool.Get().(*jsonBody)
+	body.r, body.head, body.err = r, body.head[:0], nil
+	err := json.NewDecoder(body).Decode(v)
+	switch {
+	case body.err != nil:
+		err = errors.Wrapf(body.err, "failed to read response body")
+	case err != nil:
+		// The decoder stops at the error, so read the rest of the head to report it.
+		io.CopyN(ioutil.Discard, body, int64(cap(body.head)-len(body.head)))
+		err = errors.Wrapf(err, "failed to unmarshal json: %s", redact(string(body.head)))
+	}
+	body.r = nil
+	jsonBodyPool.Put(body)
+	return err
 }
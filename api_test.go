@@ -0,0 +1,118 @@
+package pixela
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+)
+
+func TestClientHTTPTransport(t *testing.T) {
+	client := NewClient(userName, token)
+	if client.httpTransport() != client.httpTransport() {
+		t.Errorf("got: a new transport\nwant: the same transport")
+	}
+	if client.httpTransport() == defaultTransport {
+		t.Errorf("got: defaultTransport\nwant: the transport of the Client")
+	}
+
+	var nilClient *Client
+	if nilClient.httpTransport() != defaultTransport {
+		t.Errorf("got: %v\nwant: defaultTransport", nilClient.httpTransport())
+	}
+}
+
+func TestClientDoReusesConnection(t *testing.T) {
+	defer withoutClientMock()()
+
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":"Success.","isSuccess":true} `))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := NewClient(userName, token)
+	for i := 0; i < 3; i++ {
+		result, err := client.doRequestAndParseResponse(&requestParameter{Method: http.MethodPut, URL: server.URL})
+		testSuccess(t, result, err)
+	}
+
+	if got := atomic.LoadInt32(&connections); got != 1 {
+		t.Errorf("got: %d connections\nwant: 1", got)
+	}
+}
+
+func TestDecodeJSONError(t *testing.T) {
+	var result Result
+	err := decodeJSON(strings.NewReader("404 page not found"), &result, (&requestParameter{}).redact)
+
+	expect := "failed to unmarshal json: 404 page not found"
+	if err == nil || strings.HasPrefix(err.Error(), expect) == false {
+		t.Errorf("got: %v\nwant: %s", err, expect)
+	}
+}
+
+func TestDecodeJSONErrorHead(t *testing.T) {
+	var result Result
+	body := "<html>" + strings.Repeat("x", 2*maxErrorBodySize)
+	err := decodeJSON(strings.NewReader(body), &result, (&requestParameter{}).redact)
+	if err == nil {
+		t.Fatalf("got: nil\nwant: error")
+	}
+
+	expect := "failed to unmarshal json: " + body[:maxErrorBodySize] + ": "
+	if strings.HasPrefix(err.Error(), expect) == false {
+		t.Errorf("got: %v\nwant: %s", err, expect)
+	}
+}
+
+func TestDecodeJSONReadError(t *testing.T) {
+	var result Result
+	err := decodeJSON(iotest.TimeoutReader(strings.NewReader(`{"message":`)), &result, (&requestParameter{}).redact)
+
+	expect := "failed to read response body"
+	if err == nil || strings.HasPrefix(err.Error(), expect) == false {
+		t.Errorf("got: %v\nwant: %s", err, expect)
+	}
+}
+
+func BenchmarkPixelIncrement(b *testing.B) {
+	clientMock = newOKMock()
+	pixel := NewClient(userName, token).Pixel(graphID)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := pixel.Increment(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkClientDo(b *testing.B) {
+	defer withoutClientMock()()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":"Success.","isSuccess":true}`))
+	}))
+	defer server.Close()
+	client := NewClient(userName, token)
+	param := &requestParameter{Method: http.MethodPut, URL: server.URL, Header: map[string]string{userToken: token}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.doRequestAndParseResponse(param); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
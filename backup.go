@@ -0,0 +1,186 @@
+package pixela
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// BackupVersion is the version of the archive written by Backup.
+const BackupVersion = 1
+
+// BackupArchive is a snapshot of all graphs, pixels and webhooks of a user.
+type BackupArchive struct {
+	Version  int                 `json:"version"`
+	UserName string              `json:"userName"`
+	Graphs   []GraphExport       `json:"graphs"`
+	Webhooks []WebhookDefinition `json:"webhooks"`
+}
+
+// Backup writes all graph definitions, all pixels of every graph and all webhook definitions to w.
+func (c *Client) Backup(ctx context.Context, w io.Writer) error {
+	definitions, err := c.Graph("").GetAll()
+	if err := checkResult(&definitions.Result, err); err != nil {
+		return errors.Wrapf(err, "failed to get all graph definitions")
+	}
+
+	archive := BackupArchive{
+		Version:  BackupVersion,
+		UserName: c.UserName,
+		Graphs:   make([]GraphExport, 0, len(definitions.Graphs)),
+		Webhooks: []WebhookDefinition{},
+	}
+	for _, definition := range definitions.Graphs {
+		pixels, err := c.graph(definition.ID).pixelValues(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get pixels: %s", definition.ID)
+		}
+		if pixels == nil {
+			pixels = []PixelValue{}
+		}
+		archive.Graphs = append(archive.Graphs, GraphExport{Graph: definition, Pixels: pixels})
+	}
+
+	webhooks, err := c.Webhook().GetAll()
+	if err := checkResult(&webhooks.Result, err); err != nil {
+		return errors.Wrapf(err, "failed to get all webhooks definitions")
+	}
+	if webhooks.Webhooks != nil {
+		archive.Webhooks = webhooks.Webhooks
+	}
+
+	return errors.Wrap(json.NewEncoder(w).Encode(&archive), "failed to write json")
+}
+
+// Specify how Restore handles a graph that already exists.
+// skip leaves the existing graph as it is, and overwrite updates its definition and pixels.
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+)
+
+// RestoreOptions is the options of Restore.
+type RestoreOptions struct {
+	// Conflict is ConflictSkip or ConflictOverwrite. If not specified, it is treated as ConflictSkip.
+	Conflict string
+}
+
+// RestoreResult is the result of Restore.
+type RestoreResult struct {
+	Created     []string
+	Overwritten []string
+	Skipped     []string
+	// WebhookHashes maps the webhook hashes in the archive to the webhook hashes of the restored user.
+	WebhookHashes map[string]string
+}
+
+// Restore recreates the graphs, pixels and webhooks written by Backup on the user of the client.
+// Webhooks get new hashes on the user, so the result maps the hashes in the archive to them.
+func (c *Client) Restore(ctx context.Context, r io.Reader, opts *RestoreOptions) (*RestoreResult, error) {
+	if opts == nil {
+		opts = &RestoreOptions{}
+	}
+	conflict := opts.Conflict
+	if conflict == "" {
+		conflict = ConflictSkip
+	}
+	if conflict != ConflictSkip && conflict != ConflictOverwrite {
+		return nil, errors.Errorf("unsupported conflict policy: %s", conflict)
+	}
+
+	var archive BackupArchive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal json")
+	}
+	if archive.Version != BackupVersion {
+		return nil, errors.Errorf("unsupported backup version: %d", archive.Version)
+	}
+
+	definitions, err := c.Graph("").GetAll()
+	if err := checkResult(&definitions.Result, err); err != nil {
+		return nil, errors.Wrapf(err, "failed to get all graph definitions")
+	}
+	existing := make(map[string]GraphDefinition, len(definitions.Graphs))
+	for _, d := range definitions.Graphs {
+		existing[d.ID] = d
+	}
+
+	result := &RestoreResult{WebhookHashes: map[string]string{}}
+	skipped := map[string]bool{}
+	for _, export := range archive.Graphs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		d := export.Graph
+		g := c.graph(d.ID)
+		quantityType := d.Type
+		if current, ok := existing[d.ID]; ok {
+			if conflict == ConflictSkip {
+				result.Skipped = append(result.Skipped, d.ID)
+				skipped[d.ID] = true
+				continue
+			}
+			res, err := g.Update(d.Name, d.Unit, d.Color, d.TimeZone, d.PurgeCacheURLs, d.SelfSufficient, d.IsSecret, d.PublishOptionalData)
+			if err := checkResult(res, err); err != nil {
+				return result, errors.Wrapf(err, "failed to update graph: %s", d.ID)
+			}
+			quantityType = current.Type
+			result.Overwritten = append(result.Overwritten, d.ID)
+		} else {
+			res, err := g.Create(d.Name, d.Unit, d.Type, d.Color, d.TimeZone, d.SelfSufficient, d.IsSecret, d.PublishOptionalData)
+			if err := checkResult(res, err); err != nil {
+				return result, errors.Wrapf(err, "failed to create graph: %s", d.ID)
+			}
+			result.Created = append(result.Created, d.ID)
+		}
+
+		if _, err := g.importPixels(ctx, export.Pixels, quantityType, false); err != nil {
+			return result, errors.Wrapf(err, "failed to restore pixels: %s", d.ID)
+		}
+	}
+
+	if err := c.restoreWebhooks(ctx, archive.Webhooks, skipped, result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (c *Client) restoreWebhooks(ctx context.Context, webhooks []WebhookDefinition, skipped map[string]bool, result *RestoreResult) error {
+	definitions, err := c.Webhook().GetAll()
+	if err := checkResult(&definitions.Result, err); err != nil {
+		return errors.Wrapf(err, "failed to get all webhooks definitions")
+	}
+
+	for _, w := range webhooks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if hash, ok := findWebhook(definitions.Webhooks, w.GraphID, w.Type); ok {
+			result.WebhookHashes[w.WebhookHash] = hash
+			continue
+		}
+		if skipped[w.GraphID] {
+			continue
+		}
+
+		created, err := c.Webhook().Create(w.GraphID, w.Type)
+		if err := checkResult(&created.Result, err); err != nil {
+			return errors.Wrapf(err, "failed to create webhook: %s", w.GraphID)
+		}
+		result.WebhookHashes[w.WebhookHash] = created.WebhookHash
+	}
+	return nil
+}
+
+func findWebhook(definitions []WebhookDefinition, graphID, webhookType string) (string, bool) {
+	for _, d := range definitions {
+		if d.GraphID == graphID && d.Type == webhookType {
+			return d.WebhookHash, true
+		}
+	}
+	return "", false
+}
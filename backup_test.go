@@ -0,0 +1,96 @@
+package pixela
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClientBackup(t *testing.T) {
+	var requests []string
+	mock := newExportMock(map[string]string{"20180915": "5", "20180916": "3"}, &requests)
+	graphs := mock.handler
+	mock.handler = func(req *http.Request) (int, []byte) {
+		if strings.HasSuffix(req.URL.Path, "/webhooks") {
+			return http.StatusOK, []byte(`{"webhooks":[{"webhookHash":"hash","graphId":"graph-id","type":"increment"}]}`)
+		}
+		return graphs(req)
+	}
+	clientMock = mock
+
+	client := Client{UserName: userName, Token: token}
+	var buf bytes.Buffer
+	if err := client.Backup(context.Background(), &buf); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	var archive BackupArchive
+	if err := json.Unmarshal(buf.Bytes(), &archive); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	if archive.Version != BackupVersion {
+		t.Errorf("Version: %d\nwant: %d", archive.Version, BackupVersion)
+	}
+	if len(archive.Graphs) != 1 || len(archive.Graphs[0].Pixels) != 2 {
+		t.Errorf("Graphs: %v\nwant: 1 graph with 2 pixels", archive.Graphs)
+	}
+	expect := []WebhookDefinition{{WebhookHash: "hash", GraphID: graphID, Type: SelfSufficientIncrement}}
+	if reflect.DeepEqual(archive.Webhooks, expect) == false {
+		t.Errorf("Webhooks: %v\nwant: %v", archive.Webhooks, expect)
+	}
+}
+
+func TestClientRestore(t *testing.T) {
+	var requests []string
+	mock := newExportMock(map[string]string{}, &requests)
+	graphs := mock.handler
+	mock.handler = func(req *http.Request) (int, []byte) {
+		if strings.HasSuffix(req.URL.Path, "/webhooks") {
+			requests = append(requests, req.Method+" "+req.URL.Path)
+			if req.Method == http.MethodPost {
+				return http.StatusOK, []byte(`{"webhookHash":"new-hash","message":"Success.","isSuccess":true}`)
+			}
+			return http.StatusOK, []byte(`{"webhooks":[]}`)
+		}
+		return graphs(req)
+	}
+	clientMock = mock
+
+	archive := `{"version":1,"userName":"old-user","graphs":[` +
+		`{"graph":{"id":"graph-id","type":"int"},"pixels":[{"date":"20180915","quantity":"5"}]},` +
+		`{"graph":{"id":"new-graph","type":"int"},"pixels":[{"date":"20180915","quantity":"1"}]}],` +
+		`"webhooks":[{"webhookHash":"old-hash","graphId":"new-graph","type":"increment"},{"webhookHash":"skipped-hash","graphId":"graph-id","type":"increment"}]}`
+
+	client := Client{UserName: userName, Token: token}
+	result, err := client.Restore(context.Background(), strings.NewReader(archive), nil)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := &RestoreResult{
+		Created:       []string{"new-graph"},
+		Skipped:       []string{graphID},
+		WebhookHashes: map[string]string{"old-hash": "new-hash"},
+	}
+	if reflect.DeepEqual(result, expect) == false {
+		t.Errorf("got: %v\nwant: %v", result, expect)
+	}
+
+	for _, r := range requests {
+		if strings.HasPrefix(r, http.MethodGet) == false && strings.Contains(r, "/"+graphID) {
+			t.Errorf("skipped graph is changed: %s", r)
+		}
+	}
+}
+
+func TestClientRestoreUnsupportedVersion(t *testing.T) {
+	client := Client{UserName: userName, Token: token}
+	_, err := client.Restore(context.Background(), strings.NewReader(`{"version":2}`), nil)
+	if err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
@@ -0,0 +1,97 @@
+package pixela
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BulkOperation is an API call executed by BulkExecutor.
+// Wrap any call of Pixel, Graph or Webhook in a closure, for example:
+//
+//	func() (*Result, error) { return client.Pixel("graph-id").Create("20180915", "5", "") }
+type BulkOperation func() (*Result, error)
+
+// BulkResult is the result of a BulkOperation.
+// Err is the context error if the operation was not executed because of the cancellation.
+type BulkResult struct {
+	Result *Result
+	Err    error
+}
+
+const defaultBulkWorkers = 4
+
+// BulkExecutor executes BulkOperations concurrently with a bounded number of workers.
+type BulkExecutor struct {
+	// Workers is the number of operations executed at the same time. If 0, 4 is used.
+	Workers int
+	// Interval is the minimum interval between the starts of operations to limit the request rate.
+	// If 0, operations are started as soon as a worker is free.
+	Interval time.Duration
+	// Progress is called after each operation is executed with the number of executed operations and the total.
+	// It is never called concurrently.
+	Progress func(done, total int)
+}
+
+// Execute executes the operations and returns the results in the same order as the operations.
+// When ctx is done, the operations that have not started yet are not executed.
+func (b *BulkExecutor) Execute(ctx context.Context, operations []BulkOperation) []BulkResult {
+	results := make([]BulkResult, len(operations))
+	workers := b.Workers
+	if workers <= 0 {
+		workers = defaultBulkWorkers
+	}
+
+	var mu sync.Mutex
+	done := 0
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := operations[i]()
+				results[i] = BulkResult{Result: result, Err: err}
+
+				mu.Lock()
+				done++
+				if b.Progress != nil {
+					b.Progress(done, len(operations))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var tick <-chan time.Time
+	if b.Interval > 0 {
+		ticker := time.NewTicker(b.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	next := 0
+dispatch:
+	for ; next < len(operations); next++ {
+		if tick != nil && next > 0 {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := next; i < len(operations); i++ {
+		results[i] = BulkResult{Err: ctx.Err()}
+	}
+	return results
+}
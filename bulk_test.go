@@ -0,0 +1,80 @@
+package pixela
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulkExecutorExecute(t *testing.T) {
+	var running, maxRunning int32
+	operations := make([]BulkOperation, 20)
+	for i := range operations {
+		i := i
+		operations[i] = func() (*Result, error) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Duration(20-i) * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return &Result{Message: strconv.Itoa(i), IsSuccess: true}, nil
+		}
+	}
+
+	var mu sync.Mutex
+	var progress []int
+	executor := &BulkExecutor{Workers: 3, Progress: func(done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if total != len(operations) {
+			t.Errorf("total: %d\nwant: %d", total, len(operations))
+		}
+		progress = append(progress, done)
+	}}
+	results := executor.Execute(context.Background(), operations)
+
+	for i, r := range results {
+		if r.Err != nil || r.Result.Message != strconv.Itoa(i) {
+			t.Errorf("results[%d]: %v, %v\nwant: %d", i, r.Result, r.Err, i)
+		}
+	}
+	if maxRunning > 3 {
+		t.Errorf("max running: %d\nwant: <= 3", maxRunning)
+	}
+	if len(progress) != len(operations) || progress[len(progress)-1] != len(operations) {
+		t.Errorf("progress: %v", progress)
+	}
+}
+
+func TestBulkExecutorExecuteCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var executed int32
+	operations := make([]BulkOperation, 10)
+	for i := range operations {
+		operations[i] = func() (*Result, error) {
+			if atomic.AddInt32(&executed, 1) == 2 {
+				cancel()
+			}
+			return &Result{IsSuccess: true}, nil
+		}
+	}
+
+	executor := &BulkExecutor{Workers: 1, Interval: time.Millisecond}
+	results := executor.Execute(ctx, operations)
+
+	if n := atomic.LoadInt32(&executed); n >= int32(len(operations)) {
+		t.Errorf("executed: %d\nwant: < %d", n, len(operations))
+	}
+	last := results[len(results)-1]
+	if last.Err != context.Canceled {
+		t.Errorf("got: %v\nwant: %v", last.Err, context.Canceled)
+	}
+}
@@ -0,0 +1,237 @@
+package pixela
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cache stores the responses of the Pixela API.
+// Implement it to use a shared backend such as Redis.
+type Cache interface {
+	// Get returns the value of the key if it exists and is not expired.
+	Get(key string) ([]byte, bool)
+	// Set stores the value of the key for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+	// Delete removes the value of the key.
+	Delete(key string)
+	// DeletePrefix removes the values of all keys that start with prefix.
+	DeletePrefix(prefix string)
+}
+
+// CacheTTL is how long the response of each operation is cached.
+// The response of an operation whose TTL is 0 is not cached.
+type CacheTTL struct {
+	// GraphDefinitions is the TTL of Graph.GetAll.
+	GraphDefinitions time.Duration
+	// Stats is the TTL of Graph.Stats.
+	Stats time.Duration
+	// Pixel is the TTL of Pixel.Get.
+	Pixel time.Duration
+	// SVG is the TTL of Graph.GetSVG.
+	SVG time.Duration
+}
+
+// DefaultCacheTTL is the CacheTTL suitable for dashboards.
+var DefaultCacheTTL = CacheTTL{
+	GraphDefinitions: 5 * time.Minute,
+	Stats:            time.Minute,
+	Pixel:            time.Minute,
+	SVG:              time.Minute,
+}
+
+type responseCache struct {
+	cache Cache
+	ttl   CacheTTL
+}
+
+// EnableCache caches the responses of Graph.GetAll, Graph.Stats, Graph.GetSVG and Pixel.Get
+// of the Graph and Pixel returned by the Client.
+// A successful create, update or delete evicts the responses of the graph it changed.
+// If cache is nil, an LRUCache of 1000 entries is used.
+// It must be called before the Client is used.
+func (c *Client) EnableCache(cache Cache, ttl CacheTTL) {
+	if cache == nil {
+		cache = NewLRUCache(1000)
+	}
+	c.cache = &responseCache{cache: cache, ttl: ttl}
+}
+
+func cacheKey(userName, method, url string) string {
+	return userName + " " + method + " " + url
+}
+
+// cachedRequest returns the cached response of the request if it exists, and calls do and caches its response otherwise.
+func (c *Client) cachedRequest(userName string, ttl func(*CacheTTL) time.Duration, param *requestParameter, do func(*requestParameter) ([]byte, error)) ([]byte, error) {
+	if c == nil || c.cache == nil || ttl(&c.cache.ttl) <= 0 {
+		return do(param)
+	}
+
+	key := cacheKey(userName, param.Method, param.URL)
+	if b, ok := c.cache.cache.Get(key); ok {
+		return b, nil
+	}
+
+	b, err := do(param)
+	if err == nil && isCacheable(b) {
+		c.cache.cache.Set(key, b, ttl(&c.cache.ttl))
+	}
+	return b, err
+}
+
+// cachedDecode decodes the cached response of the request into v if it exists, and calls doRequestAndDecode
+// and caches the response otherwise. It returns the status code of the response, which is http.StatusOK
+// for a cached one because only successful responses are cached.
+// As the response is decoded as it is read, what is cached is v encoded again.
+func (c *Client) cachedDecode(userName string, ttl func(*CacheTTL) time.Duration, param *requestParameter, v interface{}) (int, error) {
+	if c == nil || c.cache == nil || ttl(&c.cache.ttl) <= 0 {
+		return c.doRequestAndDecode(param, v)
+	}
+
+	key := cacheKey(userName, param.Method, param.URL)
+	if b, ok := c.cache.cache.Get(key); ok {
+		return http.StatusOK, errors.Wrapf(json.Unmarshal(b, v), "failed to unmarshal json")
+	}
+
+	statusCode, err := c.doRequestAndDecode(param, v)
+	if err != nil {
+		return statusCode, err
+	}
+	if b, err := json.Marshal(v); err == nil && isCacheable(b) {
+		c.cache.cache.Set(key, b, ttl(&c.cache.ttl))
+	}
+	return statusCode, nil
+}
+
+// isCacheable reports whether the response is not an error message.
+// SVG responses are not JSON and are always cacheable because errors are returned for them.
+func isCacheable(b []byte) bool {
+	var result Result
+	if err := json.Unmarshal(b, &result); err != nil {
+		return true
+	}
+	return result.Message == ""
+}
+
+func graphDefinitionsTTL(ttl *CacheTTL) time.Duration { return ttl.GraphDefinitions }
+func statsTTL(ttl *CacheTTL) time.Duration            { return ttl.Stats }
+func pixelTTL(ttl *CacheTTL) time.Duration            { return ttl.Pixel }
+func svgTTL(ttl *CacheTTL) time.Duration              { return ttl.SVG }
+
+// invalidateGraph evicts the cached responses of the graph: stats, SVG and pixels.
+// If definitions is true, the graph definitions are evicted too.
+func (c *Client) invalidateGraph(userName, graphID string, definitions bool) {
+	if c == nil || c.cache == nil {
+		return
+	}
+
+	graphs := fmt.Sprintf(APIBaseURL+"/users/%s/graphs", userName)
+	graph := graphs + "/" + graphID
+	c.cache.cache.Delete(cacheKey(userName, http.MethodGet, graph))
+	c.cache.cache.DeletePrefix(cacheKey(userName, http.MethodGet, graph+"/"))
+	c.cache.cache.DeletePrefix(cacheKey(userName, http.MethodGet, graph+"?"))
+	if definitions {
+		c.cache.cache.Delete(cacheKey(userName, http.MethodGet, graphs))
+	}
+}
+
+// invalidateUser evicts all cached responses of the user.
+func (c *Client) invalidateUser(userName string) {
+	if c == nil || c.cache == nil {
+		return
+	}
+	c.cache.cache.DeletePrefix(userName + " ")
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used entry when it is full.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  *list.List
+	index    map[string]*list.Element
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCache returns a new LRUCache that holds up to capacity entries.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		entries:  list.New(),
+		index:    map[string]*list.Element{},
+		now:      time.Now,
+	}
+}
+
+// Get returns the value of the key if it exists and is not expired.
+func (l *LRUCache) Get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.index[key]
+	if ok == false {
+		return nil, false
+	}
+	entry := e.Value.(*lruEntry)
+	if l.now().After(entry.expires) {
+		l.remove(e)
+		return nil, false
+	}
+	l.entries.MoveToFront(e)
+	return entry.value, true
+}
+
+// Set stores the value of the key for ttl.
+func (l *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := &lruEntry{key: key, value: value, expires: l.now().Add(ttl)}
+	if e, ok := l.index[key]; ok {
+		e.Value = entry
+		l.entries.MoveToFront(e)
+		return
+	}
+	l.index[key] = l.entries.PushFront(entry)
+	for l.capacity > 0 && l.entries.Len() > l.capacity {
+		l.remove(l.entries.Back())
+	}
+}
+
+// Delete removes the value of the key.
+func (l *LRUCache) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.index[key]; ok {
+		l.remove(e)
+	}
+}
+
+// DeletePrefix removes the values of all keys that start with prefix.
+func (l *LRUCache) DeletePrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, e := range l.index {
+		if strings.HasPrefix(key, prefix) {
+			l.remove(e)
+		}
+	}
+}
+
+func (l *LRUCache) remove(e *list.Element) {
+	l.entries.Remove(e)
+	delete(l.index, e.Value.(*lruEntry).key)
+}
@@ -0,0 +1,106 @@
+package pixela
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newCountingMock(requests *[]string) *httpClientMock {
+	return &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		*requests = append(*requests, req.Method+" "+req.URL.Path)
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/users/"+userName+"/graphs":
+			return http.StatusOK, []byte(testGraphDefinitions)
+		case req.Method == http.MethodGet:
+			return http.StatusOK, []byte(`{"totalPixelsCount":1,"maxQuantity":5,"minQuantity":5,"totalQuantity":5,"avgQuantity":5,"todaysQuantity":5}`)
+		}
+		return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+	}}
+}
+
+func TestClientCacheHit(t *testing.T) {
+	var requests []string
+	clientMock = newCountingMock(&requests)
+	client := NewClient(userName, token)
+	client.EnableCache(nil, DefaultCacheTTL)
+
+	for i := 0; i < 3; i++ {
+		definitions, err := client.Graph(graphID).GetAll()
+		if err != nil || definitions.IsSuccess == false {
+			t.Fatalf("got: %v, %v\nwant: success", definitions, err)
+		}
+		stats, err := client.Graph(graphID).Stats()
+		if err != nil || stats.TotalQuantity != 5 {
+			t.Fatalf("got: %v, %v\nwant: total quantity 5", stats, err)
+		}
+	}
+
+	if len(requests) != 2 {
+		t.Errorf("got: %v\nwant: 2 requests", requests)
+	}
+}
+
+func TestClientCacheInvalidate(t *testing.T) {
+	var requests []string
+	clientMock = newCountingMock(&requests)
+	client := NewClient(userName, token)
+	client.EnableCache(nil, DefaultCacheTTL)
+
+	client.Graph(graphID).GetAll()
+	client.Graph(graphID).Stats()
+	client.Graph("other").Stats()
+
+	result, err := client.Pixel(graphID).Update("20180915", "5", "")
+	testSuccess(t, result, err)
+
+	requests = nil
+	client.Graph(graphID).GetAll()
+	client.Graph(graphID).Stats()
+	client.Graph("other").Stats()
+	expect := "GET /v1/users/user/graphs/graph-id/stats"
+	if len(requests) != 1 || requests[0] != expect {
+		t.Errorf("got: %v\nwant: [%s]", requests, expect)
+	}
+}
+
+func TestClientCacheSkipsErrors(t *testing.T) {
+	clientMock = newAPIFailedMock()
+	client := NewClient(userName, token)
+	cache := NewLRUCache(10)
+	client.EnableCache(cache, DefaultCacheTTL)
+
+	client.Graph(graphID).Stats()
+	if len(cache.index) != 0 {
+		t.Errorf("got: %d entries\nwant: 0", len(cache.index))
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	now := time.Date(2018, 9, 15, 0, 0, 0, 0, time.UTC)
+	cache := NewLRUCache(2)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", []byte("a"), time.Minute)
+	cache.Set("b", []byte("b"), time.Minute)
+	cache.Get("a")
+	cache.Set("c", []byte("c"), time.Minute)
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("b was not evicted")
+	}
+	if v, ok := cache.Get("a"); ok == false || string(v) != "a" {
+		t.Errorf("got: %s, %v\nwant: a, true", v, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("a was not expired")
+	}
+
+	cache.Set("x/1", []byte("1"), time.Minute)
+	cache.Set("x/2", []byte("2"), time.Minute)
+	cache.DeletePrefix("x/")
+	if len(cache.index) != 0 || cache.entries.Len() != 0 {
+		t.Errorf("got: %d entries\nwant: 0", len(cache.index))
+	}
+}
@@ -1,9 +1,63 @@
 package pixela
 
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
 // A Client manages communication with the Pixela User API.
+// It is safe for concurrent use once its first resource or request is made.
 type Client struct {
 	UserName string
-	Token    string
+	// Token is the token of the user. UpdateUser and RotateToken update it,
+	// so use CurrentToken to read it while the Client is in use from other goroutines.
+	Token string
+
+	credentials CredentialsProvider
+	cache       *responseCache
+	logger      Logger
+	verbose     bool
+	dryRun      *DryRun
+
+	mu         sync.Mutex
+	validators *LRUCache
+	state      *credentialState
+	transport  *transport
+}
+
+// credentialState is the user name and the token shared by a Client and the resources derived from it,
+// so that a rotated token reaches all of them at once.
+type credentialState struct {
+	mu       sync.RWMutex
+	userName string
+	token    string
+}
+
+// userNameOr returns the shared user name, or userName if s is nil.
+func (s *credentialState) userNameOr(userName string) string {
+	if s == nil {
+		return userName
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.userName
+}
+
+// tokenOr returns the shared token, or token if s is nil.
+func (s *credentialState) tokenOr(token string) string {
+	if s == nil {
+		return token
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.token
+}
+
+func (s *credentialState) setToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
 }
 
 // NewClient return a new Client instance.
@@ -17,34 +71,102 @@ func (c *Client) CreateUser(agreeTermsOfService, notMinor bool, thanksCode strin
 }
 
 func (c *Client) user() *user {
-	return &user{UserName: c.UserName, Token: c.Token}
+	state := c.shared()
+	return &user{UserName: state.userNameOr(""), Token: state.tokenOr(""), client: c}
+}
+
+// CurrentToken returns the token the Client and its resources currently use.
+func (c *Client) CurrentToken() string {
+	return c.shared().tokenOr("")
+}
+
+// shared returns the credential state of the Client, creating it from UserName and Token at first.
+func (c *Client) shared() *credentialState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == nil {
+		c.state = &credentialState{userName: c.UserName, token: c.Token}
+	}
+	return c.state
+}
+
+// setToken switches the Client and all resources derived from it to the token.
+func (c *Client) setToken(token string) {
+	state := c.shared()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state.setToken(token)
+	c.Token = token
 }
 
 // UpdateUser updates the authentication token for the specified user.
+// If the Client was created by NewClientWithCredentials with a CredentialsStore,
+// the new token is stored as pending before the update and the result is stored after it.
+// If the update fails without a response, the pending token is left so that it can be recovered.
+// In the dry-run mode, the update is only recorded and neither the token nor the credentials change.
 func (c *Client) UpdateUser(newToken, thanksCode string) (*Result, error) {
+	if c.inDryRun() {
+		return c.user().Update(newToken, thanksCode)
+	}
+
+	store, persist := c.credentials.(CredentialsStore)
+	if persist {
+		pending := &Credentials{UserName: c.UserName, Token: c.CurrentToken(), PendingToken: newToken}
+		if err := store.Store(pending); err != nil {
+			return &Result{}, errors.Wrapf(err, "failed to store pending token")
+		}
+	}
+
 	result, err := c.user().Update(newToken, thanksCode)
-	if err == nil && result.IsSuccess {
-		c.Token = newToken
+	if err != nil {
+		return result, err
 	}
-	return result, err
+	if result.IsSuccess {
+		c.setToken(newToken)
+	}
+	if persist {
+		if err := store.Store(&Credentials{UserName: c.UserName, Token: c.CurrentToken()}); err != nil {
+			return result, errors.Wrapf(err, "failed to store token")
+		}
+	}
+	return result, nil
 }
 
 // DeleteUser deletes the specified registered user.
 func (c *Client) DeleteUser() (*Result, error) {
-	return c.user().Delete()
+	result, err := c.user().Delete()
+	if err == nil && result.IsSuccess {
+		c.invalidateUser(c.UserName)
+	}
+	return result, err
 }
 
 // Graph returns a new Pixela graph API client.
-func (c *Client) Graph(graphID string) *Graph {
-	return &Graph{UserName: c.UserName, Token: c.Token, GraphID: graphID}
+func (c *Client) Graph(graphID string) GraphService {
+	return c.graph(graphID)
+}
+
+func (c *Client) graph(graphID string) *Graph {
+	state := c.shared()
+	return &Graph{UserName: state.userNameOr(""), Token: state.tokenOr(""), GraphID: graphID, client: c, state: state}
 }
 
 // Pixel returns a new Pixela pixel API client.
-func (c *Client) Pixel(graphID string) *Pixel {
-	return &Pixel{UserName: c.UserName, Token: c.Token, GraphID: graphID}
+func (c *Client) Pixel(graphID string) PixelService {
+	return c.pixel(graphID)
+}
+
+func (c *Client) pixel(graphID string) *Pixel {
+	state := c.shared()
+	return &Pixel{UserName: state.userNameOr(""), Token: state.tokenOr(""), GraphID: graphID, client: c, state: state}
 }
 
 // Webhook returns a new Pixela webhook API client.
-func (c *Client) Webhook() *Webhook {
-	return &Webhook{UserName: c.UserName, Token: c.Token}
+func (c *Client) Webhook() WebhookService {
+	return c.webhook()
+}
+
+func (c *Client) webhook() *Webhook {
+	state := c.shared()
+	return &Webhook{UserName: state.userNameOr(""), Token: state.tokenOr(""), client: c, state: state}
 }
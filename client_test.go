@@ -0,0 +1,79 @@
+package pixela
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestClientUpdateUserReachesResources(t *testing.T) {
+	clientMock = newOKMock()
+
+	client := NewClient(userName, token)
+	graph := client.Graph(graphID)
+	pixel := client.Pixel(graphID)
+	webhook := client.Webhook()
+
+	client.UpdateUser("newToken", "thanks-code")
+
+	var tokens []string
+	clientMock.handler = func(req *http.Request) (int, []byte) {
+		tokens = append(tokens, req.Header.Get(userToken))
+		return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+	}
+	graph.Delete()
+	pixel.Increment()
+	webhook.Delete("hash")
+
+	if len(tokens) != 3 {
+		t.Fatalf("got: %v\nwant: 3 requests", tokens)
+	}
+	for _, got := range tokens {
+		if got != "newToken" {
+			t.Errorf("got: %s\nwant: newToken", got)
+		}
+	}
+	if client.CurrentToken() != "newToken" {
+		t.Errorf("got: %s\nwant: newToken", client.CurrentToken())
+	}
+}
+
+func TestClientConcurrentUpdateUser(t *testing.T) {
+	clientMock = newOKMock()
+
+	client := NewClient(userName, token)
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	clientMock.handler = func(req *http.Request) (int, []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[req.Header.Get(userToken)] = true
+		return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pixel := client.Pixel(graphID)
+			for j := 0; j < 5; j++ {
+				pixel.Increment()
+				client.Graph(graphID).Delete()
+			}
+		}()
+	}
+	for _, newToken := range []string{"newToken1", "newToken2"} {
+		client.UpdateUser(newToken, "")
+	}
+	wg.Wait()
+
+	for got := range seen {
+		if got != token && got != "newToken1" && got != "newToken2" {
+			t.Errorf("got: %s\nwant: one of the tokens", got)
+		}
+	}
+	if client.CurrentToken() != "newToken2" {
+		t.Errorf("got: %s\nwant: newToken2", client.CurrentToken())
+	}
+}
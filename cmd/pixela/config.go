@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Environment variables that override the config file.
+const (
+	envUserName = "PIXELA_USER_NAME"
+	envToken    = "PIXELA_TOKEN"
+	envConfig   = "PIXELA_CONFIG"
+)
+
+type config struct {
+	UserName string `json:"username"`
+	Token    string `json:"token"`
+}
+
+// loadConfig reads the config file and overrides it with the environment variables.
+// A missing config file is not an error unless the path is specified explicitly.
+func loadConfig(path string, getenv func(string) string) (*config, error) {
+	if path == "" {
+		path = getenv(envConfig)
+	}
+	explicit := path != ""
+	if explicit == false {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			path = filepath.Join(home, ".config", "pixela", "config.json")
+		}
+	}
+
+	var conf config
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(b, &conf); err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal config file: %s", path)
+			}
+		case os.IsNotExist(err) && explicit == false:
+		default:
+			return nil, errors.Wrapf(err, "failed to read config file")
+		}
+	}
+
+	if v := getenv(envUserName); v != "" {
+		conf.UserName = v
+	}
+	if v := getenv(envToken); v != "" {
+		conf.Token = v
+	}
+	if conf.UserName == "" {
+		return nil, errors.Errorf("user name is not configured: set %s or the config file", envUserName)
+	}
+	return &conf, nil
+}
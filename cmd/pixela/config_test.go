@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newGetenv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// withTempHome points HOME and XDG_CONFIG_HOME at an empty directory so that the config file of the user is not read.
+// The returned function restores them.
+func withTempHome(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "pixela-home")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{"HOME", "XDG_CONFIG_HOME"}
+	saved := map[string]*string{}
+	for _, key := range keys {
+		if v, ok := os.LookupEnv(key); ok {
+			saved[key] = &v
+		} else {
+			saved[key] = nil
+		}
+		os.Setenv(key, dir)
+	}
+	return func() {
+		for _, key := range keys {
+			if saved[key] == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *saved[key])
+			}
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pixela")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"username":"file-user","token":"file-token"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := loadConfig(path, newGetenv(map[string]string{envToken: "env-token"}))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := config{UserName: "file-user", Token: "env-token"}
+	if *conf != expect {
+		t.Errorf("got: %v\nwant: %v", *conf, expect)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	_, err := loadConfig("/not/found/config.json", newGetenv(map[string]string{envUserName: "user"}))
+	if err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestLoadConfigNoUserName(t *testing.T) {
+	defer withTempHome(t)()
+
+	_, err := loadConfig("", newGetenv(map[string]string{envConfig: "", envToken: "token"}))
+	if err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/pkg/errors"
+)
+
+var graphCommands = map[string]command{
+	"create": {usage: "create a new graph", run: graphCreate},
+	"list":   {usage: "list all graph definitions", run: graphList},
+	"get":    {usage: "get a graph definition", run: graphGet},
+	"update": {usage: "update a graph definition", run: graphUpdate},
+	"delete": {usage: "delete a graph", run: graphDelete},
+	"svg":    {usage: "get a graph as SVG", run: graphSVG},
+	"stats":  {usage: "get statistics of a graph", run: graphStats},
+	"pixels": {usage: "list the dates of pixels in a graph", run: graphPixels},
+}
+
+func graphIDFlag(flags *flag.FlagSet) *string {
+	return flags.String("id", "", "graph id (required)")
+}
+
+func requireGraphID(id string) error {
+	if id == "" {
+		return errors.New("-id is required")
+	}
+	return nil
+}
+
+func graphCreate(a *app, args []string) error {
+	flags := a.newFlagSet("graph create")
+	id := graphIDFlag(flags)
+	name := flags.String("name", "", "graph name")
+	unit := flags.String("unit", "", "unit of quantity")
+	quantityType := flags.String("type", pixela.TypeInt, "type of quantity: int or float")
+	color := flags.String("color", pixela.ColorShibafu, "display color: shibafu, momiji, sora, ichou, ajisai or kuro")
+	timezone := flags.String("timezone", "", "timezone of the graph")
+	selfSufficient := flags.String("self-sufficient", pixela.SelfSufficientNone, "increment, decrement or none")
+	secret := flags.Bool("secret", false, "hide the graph from the graph list")
+	publishOptionalData := flags.Bool("publish-optional-data", false, "publish optional data of pixels")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireGraphID(*id); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.Graph(*id).Create(*name, *unit, *quantityType, *color, *timezone, *selfSufficient, *secret, *publishOptionalData))
+}
+
+func graphList(a *app, args []string) error {
+	flags := a.newFlagSet("graph list")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	definitions, err := a.client.Graph("").GetAll()
+	if err != nil {
+		return err
+	}
+	if err := failure(&definitions.Result); err != nil {
+		return err
+	}
+	return a.print(definitions.Graphs, func(w io.Writer) {
+		printGraphDefinitions(w, definitions.Graphs...)
+	})
+}
+
+func printGraphDefinitions(w io.Writer, definitions ...pixela.GraphDefinition) {
+	fmt.Fprintln(w, "ID\tNAME\tUNIT\tTYPE\tCOLOR\tTIMEZONE\tSELF SUFFICIENT\tSECRET\tPUBLISH OPTIONAL DATA")
+	for _, d := range definitions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%t\t%t\n",
+			d.ID, d.Name, d.Unit, d.Type, d.Color, d.TimeZone, d.SelfSufficient, d.IsSecret, d.PublishOptionalData)
+	}
+}
+
+func findGraph(a *app, id string) (*pixela.GraphDefinition, error) {
+	definitions, err := a.client.Graph(id).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if err := failure(&definitions.Result); err != nil {
+		return nil, err
+	}
+	for i := range definitions.Graphs {
+		if definitions.Graphs[i].ID == id {
+			return &definitions.Graphs[i], nil
+		}
+	}
+	return nil, errors.Errorf("graph not found: %s", id)
+}
+
+func graphGet(a *app, args []string) error {
+	flags := a.newFlagSet("graph get")
+	id := graphIDFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireGraphID(*id); err != nil {
+		return err
+	}
+
+	definition, err := findGraph(a, *id)
+	if err != nil {
+		return err
+	}
+	return a.print(definition, func(w io.Writer) {
+		printGraphDefinitions(w, *definition)
+	})
+}
+
+// graphUpdate updates only the fields specified by the flags and keeps the others as they are.
+func graphUpdate(a *app, args []string) error {
+	flags := a.newFlagSet("graph update")
+	id := graphIDFlag(flags)
+	name := flags.String("name", "", "graph name")
+	unit := flags.String("unit", "", "unit of quantity")
+	color := flags.String("color", "", "display color: shibafu, momiji, sora, ichou, ajisai or kuro")
+	timezone := flags.String("timezone", "", "timezone of the graph")
+	purgeCacheURLs := flags.String("purge-cache-urls", "", "comma separated URLs to purge the cache")
+	selfSufficient := flags.String("self-sufficient", "", "increment, decrement or none")
+	secret := flags.Bool("secret", false, "hide the graph from the graph list")
+	publishOptionalData := flags.Bool("publish-optional-data", false, "publish optional data of pixels")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireGraphID(*id); err != nil {
+		return err
+	}
+
+	d, err := findGraph(a, *id)
+	if err != nil {
+		return err
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			d.Name = *name
+		case "unit":
+			d.Unit = *unit
+		case "color":
+			d.Color = *color
+		case "timezone":
+			d.TimeZone = *timezone
+		case "purge-cache-urls":
+			d.PurgeCacheURLs = splitList(*purgeCacheURLs)
+		case "self-sufficient":
+			d.SelfSufficient = *selfSufficient
+		case "secret":
+			d.IsSecret = *secret
+		case "publish-optional-data":
+			d.PublishOptionalData = *publishOptionalData
+		}
+	})
+
+	return a.printResult(a.client.Graph(*id).Update(d.Name, d.Unit, d.Color, d.TimeZone, d.PurgeCacheURLs, d.SelfSufficient, d.IsSecret, d.PublishOptionalData))
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func graphDelete(a *app, args []string) error {
+	flags := a.newFlagSet("graph delete")
+	id := graphIDFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireGraphID(*id); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.Graph(*id).Delete())
+}
+
+func graphSVG(a *app, args []string) error {
+	flags := a.newFlagSet("graph svg")
+	id := graphIDFlag(flags)
+	date := flags.String("date", "", "the last date of the graph (yyyyMMdd)")
+	mode := flags.String("mode", "", "display mode: short, badge or line")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireGraphID(*id); err != nil {
+		return err
+	}
+
+	svg, err := a.client.Graph(*id).GetSVG(*date, *mode)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(a.out, svg)
+	return err
+}
+
+func graphStats(a *app, args []string) error {
+	flags := a.newFlagSet("graph stats")
+	id := graphIDFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireGraphID(*id); err != nil {
+		return err
+	}
+
+	stats, err := a.client.Graph(*id).Stats()
+	if err != nil {
+		return err
+	}
+	if err := failure(&stats.Result); err != nil {
+		return err
+	}
+	return a.print(stats, func(w io.Writer) {
+		fmt.Fprintln(w, "TOTAL PIXELS\tMAX\tMIN\tTOTAL\tAVG\tTODAY")
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%g\t%d\n",
+			stats.TotalPixelsCount, stats.MaxQuantity, stats.MinQuantity, stats.TotalQuantity, stats.AvgQuantity, stats.TodaysQuantity)
+	})
+}
+
+func graphPixels(a *app, args []string) error {
+	flags := a.newFlagSet("graph pixels")
+	id := graphIDFlag(flags)
+	from := flags.String("from", "", "the first date (yyyyMMdd)")
+	to := flags.String("to", "", "the last date (yyyyMMdd)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireGraphID(*id); err != nil {
+		return err
+	}
+
+	pixels, err := a.client.Graph(*id).GetPixelDates(*from, *to)
+	if err != nil {
+		return err
+	}
+	if err := failure(&pixels.Result); err != nil {
+		return err
+	}
+	return a.print(pixels.Pixels, func(w io.Writer) {
+		fmt.Fprintln(w, "DATE")
+		for _, date := range pixels.Pixels {
+			fmt.Fprintln(w, date)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+// Command pixela is a command line client for Pixela.
+//
+// Usage:
+//
+//	pixela [-config path] [-o table|json] <resource> <command> [flags]
+//
+// The user name and the token are read from the PIXELA_USER_NAME and PIXELA_TOKEN environment variables,
+// or from the config file ($HOME/.config/pixela/config.json by default).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ebc-2in2crc/pixela-client-go"
+)
+
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+var resources = map[string]map[string]command{
+	"user":    userCommands,
+	"graph":   graphCommands,
+	"pixel":   pixelCommands,
+	"webhook": webhookCommands,
+}
+
+// api is the Pixela API the commands call. *pixela.Client implements it.
+type api interface {
+	pixela.UserService
+	Graph(graphID string) pixela.GraphService
+	Pixel(graphID string) pixela.PixelService
+	Webhook() pixela.WebhookService
+}
+
+// newClient returns the client of the user. Tests replace it with a fake.
+var newClient = func(userName, token string) api {
+	return pixela.NewClient(userName, token)
+}
+
+type app struct {
+	out    io.Writer
+	errOut io.Writer
+	format string
+	client api
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
+}
+
+func run(args []string, out, errOut io.Writer, getenv func(string) string) int {
+	flags := flag.NewFlagSet("pixela", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	configPath := flags.String("config", "", "path to the config file")
+	format := flags.String("o", formatTable, "output format: table or json")
+	flags.Usage = func() { usage(errOut, flags) }
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != formatTable && *format != formatJSON {
+		fmt.Fprintf(errOut, "unsupported output format: %s\n", *format)
+		return 2
+	}
+
+	args = flags.Args()
+	if len(args) < 2 {
+		usage(errOut, flags)
+		return 2
+	}
+	commands, ok := resources[args[0]]
+	if ok == false {
+		fmt.Fprintf(errOut, "unknown resource: %s\n", args[0])
+		return 2
+	}
+	cmd, ok := commands[args[1]]
+	if ok == false {
+		fmt.Fprintf(errOut, "unknown command: %s %s\n", args[0], args[1])
+		return 2
+	}
+
+	conf, err := loadConfig(*configPath, getenv)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	a := &app{out: out, errOut: errOut, format: *format, client: newClient(conf.UserName, conf.Token)}
+	if err := cmd.run(a, args[2:]); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: pixela [-config path] [-o table|json] <resource> <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options:")
+	flags.PrintDefaults()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	var names []string
+	for resource, commands := range resources {
+		for name := range commands {
+			names = append(names, resource+" "+name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := strings.SplitN(name, " ", 2)
+		fmt.Fprintf(w, "  %-16s %s\n", name, resources[s[0]][s[1]].usage)
+	}
+}
+
+func (a *app) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.errOut)
+	return flags
+}
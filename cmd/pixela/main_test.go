@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/ebc-2in2crc/pixela-client-go/pixelafake"
+)
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		args   []string
+		expect string
+	}{
+		{args: []string{}, expect: "Usage: pixela"},
+		{args: []string{"foo", "list"}, expect: "unknown resource: foo"},
+		{args: []string{"graph", "foo"}, expect: "unknown command: graph foo"},
+		{args: []string{"-o", "xml", "graph", "list"}, expect: "unsupported output format: xml"},
+	}
+
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+		code := run(tt.args, &out, &errOut, newGetenv(map[string]string{envUserName: "user"}))
+		if code != 2 {
+			t.Errorf("%v: exit code: %d\nwant: 2", tt.args, code)
+		}
+		if strings.Contains(errOut.String(), tt.expect) == false {
+			t.Errorf("%v: got: %s\nwant: %s", tt.args, errOut.String(), tt.expect)
+		}
+	}
+}
+
+func TestRunRequiredFlag(t *testing.T) {
+	defer withTempHome(t)()
+
+	var out, errOut bytes.Buffer
+	code := run([]string{"graph", "stats"}, &out, &errOut, newGetenv(map[string]string{envUserName: "user"}))
+	if code != 1 {
+		t.Errorf("exit code: %d\nwant: 1", code)
+	}
+	if strings.Contains(errOut.String(), "-id is required") == false {
+		t.Errorf("got: %s\nwant: -id is required", errOut.String())
+	}
+}
+
+func TestRunFlagErrorOutput(t *testing.T) {
+	defer withTempHome(t)()
+
+	var out, errOut bytes.Buffer
+	code := run([]string{"graph", "stats", "-unknown"}, &out, &errOut, newGetenv(map[string]string{envUserName: "user"}))
+	if code != 1 {
+		t.Errorf("exit code: %d\nwant: 1", code)
+	}
+	if strings.Contains(errOut.String(), "flag provided but not defined: -unknown") == false {
+		t.Errorf("got: %s\nwant: the flag error written to errOut", errOut.String())
+	}
+}
+
+// TestRunCommands runs every command against a fake of Pixela and checks that it succeeds.
+func TestRunCommands(t *testing.T) {
+	defer withTempHome(t)()
+
+	store := pixelafake.NewStore("user", "secret-token")
+	store.Now = func() time.Time { return time.Date(2018, 9, 15, 12, 0, 0, 0, time.UTC) }
+	defer func(f func(userName, token string) api) { newClient = f }(newClient)
+	newClient = func(userName, token string) api { return store }
+
+	tests := []struct {
+		args   string
+		expect string
+	}{
+		{args: "user create -agree-terms-of-service -not-minor", expect: "Success."},
+		{args: "graph create -id graph-id -name name -unit commit", expect: "Success."},
+		{args: "graph list", expect: "graph-id"},
+		{args: "graph get -id graph-id", expect: "commit"},
+		{args: "graph update -id graph-id -color momiji", expect: "Success."},
+		{args: "pixel create -graph graph-id -date 20180914 -quantity 5", expect: "Success."},
+		{args: "pixel get -graph graph-id -date 20180914", expect: "20180914  5"},
+		{args: "pixel update -graph graph-id -date 20180914 -quantity 7", expect: "Success."},
+		{args: "pixel inc -graph graph-id", expect: "Success."},
+		{args: "pixel dec -graph graph-id", expect: "Success."},
+		{args: "graph stats -id graph-id", expect: "TOTAL PIXELS"},
+		{args: "graph pixels -id graph-id -from 20180901 -to 20180930", expect: "20180914"},
+		{args: "graph svg -id graph-id", expect: "<svg"},
+		{args: "webhook create -graph graph-id", expect: "hash-1"},
+		{args: "webhook list", expect: "hash-1"},
+		{args: "webhook invoke -hash hash-1", expect: "Success."},
+		{args: "webhook delete -hash hash-1", expect: "Success."},
+		{args: "pixel delete -graph graph-id -date 20180914", expect: "Success."},
+		{args: "graph delete -id graph-id", expect: "Success."},
+		{args: "user update -new-token new-secret-token", expect: "Success."},
+		{args: "user delete", expect: "Success."},
+	}
+
+	commands := map[string]bool{}
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+		args := strings.Fields(tt.args)
+		code := run(args, &out, &errOut, newGetenv(map[string]string{envUserName: "user", envToken: "secret-token"}))
+		if code != 0 {
+			t.Errorf("%s: exit code: %d: %s\nwant: 0", tt.args, code, errOut.String())
+		}
+		if strings.Contains(out.String(), tt.expect) == false {
+			t.Errorf("%s: got: %s\nwant: %s", tt.args, out.String(), tt.expect)
+		}
+		commands[args[0]+" "+args[1]] = true
+	}
+
+	for resource, cmds := range resources {
+		for name := range cmds {
+			if commands[resource+" "+name] == false {
+				t.Errorf("%s %s: not tested", resource, name)
+			}
+		}
+	}
+}
+
+func TestPrintResult(t *testing.T) {
+	result := &pixela.Result{Message: "Success.", IsSuccess: true}
+
+	var out bytes.Buffer
+	a := &app{out: &out, format: formatTable}
+	if err := a.printResult(result, nil); err != nil {
+		t.Errorf("got: %v\nwant: nil", err)
+	}
+	expect := "MESSAGE   SUCCESS\nSuccess.  true\n"
+	if out.String() != expect {
+		t.Errorf("got: %q\nwant: %q", out.String(), expect)
+	}
+
+	out.Reset()
+	a.format = formatJSON
+	if err := a.printResult(result, nil); err != nil {
+		t.Errorf("got: %v\nwant: nil", err)
+	}
+	expect = "{\n  \"message\": \"Success.\",\n  \"isSuccess\": true\n}\n"
+	if out.String() != expect {
+		t.Errorf("got: %q\nwant: %q", out.String(), expect)
+	}
+}
+
+func TestPrintResultFail(t *testing.T) {
+	var out bytes.Buffer
+	a := &app{out: &out, format: formatTable}
+	err := a.printResult(&pixela.Result{Message: "failed.", IsSuccess: false}, nil)
+	if err == nil || err.Error() != "failed to call API: failed." {
+		t.Errorf("got: %v\nwant: failed to call API: failed.", err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/pkg/errors"
+)
+
+// Supported output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// print writes v as JSON or as the table written by table.
+func (a *app) print(v interface{}, table func(w io.Writer)) error {
+	if a.format == formatJSON {
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return errors.Wrap(enc.Encode(v), "failed to write json")
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	table(w)
+	return errors.Wrap(w.Flush(), "failed to write table")
+}
+
+// printResult prints the result and returns an error if the API call failed.
+func (a *app) printResult(result *pixela.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if err := a.print(result, func(w io.Writer) {
+		fmt.Fprintln(w, "MESSAGE\tSUCCESS")
+		fmt.Fprintf(w, "%s\t%t\n", result.Message, result.IsSuccess)
+	}); err != nil {
+		return err
+	}
+	return failure(result)
+}
+
+// failure returns an error if the API call failed.
+func failure(result *pixela.Result) error {
+	if result.IsSuccess {
+		return nil
+	}
+	return errors.Errorf("failed to call API: %s", result.Message)
+}
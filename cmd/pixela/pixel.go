@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+var pixelCommands = map[string]command{
+	"create": {usage: "register a quantity as a pixel", run: pixelCreate},
+	"get":    {usage: "get a pixel", run: pixelGet},
+	"update": {usage: "update a pixel", run: pixelUpdate},
+	"delete": {usage: "delete a pixel", run: pixelDelete},
+	"inc":    {usage: "increment the pixel of today", run: pixelIncrement},
+	"dec":    {usage: "decrement the pixel of today", run: pixelDecrement},
+}
+
+func pixelFlags(flags *flag.FlagSet) (graphID, date *string) {
+	graphID = flags.String("graph", "", "graph id (required)")
+	date = flags.String("date", "", "date of the pixel (yyyyMMdd, required)")
+	return graphID, date
+}
+
+func requirePixel(graphID, date string) error {
+	if graphID == "" {
+		return errors.New("-graph is required")
+	}
+	if date == "" {
+		return errors.New("-date is required")
+	}
+	return nil
+}
+
+func pixelCreate(a *app, args []string) error {
+	flags := a.newFlagSet("pixel create")
+	graphID, date := pixelFlags(flags)
+	quantity := flags.String("quantity", "", "quantity of the pixel")
+	optionalData := flags.String("optional-data", "", "optional data in JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requirePixel(*graphID, *date); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.Pixel(*graphID).Create(*date, *quantity, *optionalData))
+}
+
+func pixelGet(a *app, args []string) error {
+	flags := a.newFlagSet("pixel get")
+	graphID, date := pixelFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requirePixel(*graphID, *date); err != nil {
+		return err
+	}
+
+	quantity, err := a.client.Pixel(*graphID).Get(*date)
+	if err != nil {
+		return err
+	}
+	if err := failure(&quantity.Result); err != nil {
+		return err
+	}
+	return a.print(quantity, func(w io.Writer) {
+		fmt.Fprintln(w, "DATE\tQUANTITY\tOPTIONAL DATA")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", *date, quantity.Quantity, quantity.OptionalData)
+	})
+}
+
+func pixelUpdate(a *app, args []string) error {
+	flags := a.newFlagSet("pixel update")
+	graphID, date := pixelFlags(flags)
+	quantity := flags.String("quantity", "", "quantity of the pixel")
+	optionalData := flags.String("optional-data", "", "optional data in JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requirePixel(*graphID, *date); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.Pixel(*graphID).Update(*date, *quantity, *optionalData))
+}
+
+func pixelDelete(a *app, args []string) error {
+	flags := a.newFlagSet("pixel delete")
+	graphID, date := pixelFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requirePixel(*graphID, *date); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.Pixel(*graphID).Delete(*date))
+}
+
+func pixelIncrement(a *app, args []string) error {
+	flags := a.newFlagSet("pixel inc")
+	graphID := flags.String("graph", "", "graph id (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *graphID == "" {
+		return errors.New("-graph is required")
+	}
+
+	return a.printResult(a.client.Pixel(*graphID).Increment())
+}
+
+func pixelDecrement(a *app, args []string) error {
+	flags := a.newFlagSet("pixel dec")
+	graphID := flags.String("graph", "", "graph id (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *graphID == "" {
+		return errors.New("-graph is required")
+	}
+
+	return a.printResult(a.client.Pixel(*graphID).Decrement())
+}
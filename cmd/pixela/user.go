@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+)
+
+var userCommands = map[string]command{
+	"create": {usage: "create a new user", run: userCreate},
+	"update": {usage: "update the token of the user", run: userUpdate},
+	"delete": {usage: "delete the user", run: userDelete},
+}
+
+func userCreate(a *app, args []string) error {
+	flags := a.newFlagSet("user create")
+	agree := flags.Bool("agree-terms-of-service", false, "agree to the terms of service")
+	notMinor := flags.Bool("not-minor", false, "you are not a minor, or have the consent of the parents")
+	thanksCode := flags.String("thanks-code", "", "thanks code")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.CreateUser(*agree, *notMinor, *thanksCode))
+}
+
+func userUpdate(a *app, args []string) error {
+	flags := a.newFlagSet("user update")
+	newToken := flags.String("new-token", "", "new token (required)")
+	thanksCode := flags.String("thanks-code", "", "thanks code")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *newToken == "" {
+		return errors.New("-new-token is required")
+	}
+
+	return a.printResult(a.client.UpdateUser(*newToken, *thanksCode))
+}
+
+func userDelete(a *app, args []string) error {
+	flags := a.newFlagSet("user delete")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.DeleteUser())
+}
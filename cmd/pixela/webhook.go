@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/pkg/errors"
+)
+
+var webhookCommands = map[string]command{
+	"create": {usage: "create a new webhook", run: webhookCreate},
+	"list":   {usage: "list all webhook definitions", run: webhookList},
+	"invoke": {usage: "invoke a webhook", run: webhookInvoke},
+	"delete": {usage: "delete a webhook", run: webhookDelete},
+}
+
+func webhookCreate(a *app, args []string) error {
+	flags := a.newFlagSet("webhook create")
+	graphID := flags.String("graph", "", "graph id (required)")
+	webhookType := flags.String("type", pixela.SelfSufficientIncrement, "increment or decrement")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *graphID == "" {
+		return errors.New("-graph is required")
+	}
+
+	result, err := a.client.Webhook().Create(*graphID, *webhookType)
+	if err != nil {
+		return err
+	}
+	if err := failure(&result.Result); err != nil {
+		return err
+	}
+	return a.print(result, func(w io.Writer) {
+		fmt.Fprintln(w, "WEBHOOK HASH")
+		fmt.Fprintln(w, result.WebhookHash)
+	})
+}
+
+func webhookList(a *app, args []string) error {
+	flags := a.newFlagSet("webhook list")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	definitions, err := a.client.Webhook().GetAll()
+	if err != nil {
+		return err
+	}
+	if err := failure(&definitions.Result); err != nil {
+		return err
+	}
+	return a.print(definitions.Webhooks, func(w io.Writer) {
+		fmt.Fprintln(w, "WEBHOOK HASH\tGRAPH ID\tTYPE")
+		for _, d := range definitions.Webhooks {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", d.WebhookHash, d.GraphID, d.Type)
+		}
+	})
+}
+
+func requireWebhookHash(hash string) error {
+	if hash == "" {
+		return errors.New("-hash is required")
+	}
+	return nil
+}
+
+func webhookInvoke(a *app, args []string) error {
+	flags := a.newFlagSet("webhook invoke")
+	hash := flags.String("hash", "", "webhook hash (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireWebhookHash(*hash); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.Webhook().Invoke(*hash))
+}
+
+func webhookDelete(a *app, args []string) error {
+	flags := a.newFlagSet("webhook delete")
+	hash := flags.String("hash", "", "webhook hash (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireWebhookHash(*hash); err != nil {
+		return err
+	}
+
+	return a.printResult(a.client.Webhook().Delete(*hash))
+}
@@ -0,0 +1,114 @@
+package pixela
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	eTag            = "ETag"
+	lastModified    = "Last-Modified"
+	ifNoneMatch     = "If-None-Match"
+	ifModifiedSince = "If-Modified-Since"
+)
+
+// maxSVGValidators is the number of SVGs whose validators are kept by a Client.
+const maxSVGValidators = 100
+
+// SVG is a graph expressed in SVG format with the validators of the response.
+type SVG struct {
+	Body         string `json:"body"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"lastModified"`
+	// Unchanged is true if the server responded 304 Not Modified and Body is the one received before.
+	Unchanged bool `json:"-"`
+}
+
+// GetSVGConditional gets a graph expressed in SVG format like GetSVG.
+// If the same SVG was received before, it is requested with If-None-Match and If-Modified-Since,
+// and the SVG received before is returned with Unchanged set to true when it is not modified.
+// The validators are kept only by the Graph returned by the Client.
+func (g *Graph) GetSVGConditional(date, mode string) (*SVG, error) {
+	param, err := g.createGetSVGRequestParameter(date, mode)
+	if err != nil {
+		return &SVG{}, errors.Wrapf(err, "failed to create get svg parameter")
+	}
+
+	svg, err := g.client.getSVG(g.userName(), param)
+	if err != nil {
+		return &SVG{}, errors.Wrapf(err, "failed to do request")
+	}
+
+	return svg, nil
+}
+
+func (c *Client) getSVG(userName string, param *requestParameter) (*SVG, error) {
+	validators := c.svgValidators()
+	key := cacheKey(userName, param.Method, param.URL)
+
+	var before *SVG
+	if validators != nil {
+		if b, ok := validators.Get(key); ok {
+			before = &SVG{}
+			if err := json.Unmarshal(b, before); err != nil {
+				return &SVG{}, errors.Wrapf(err, "failed to unmarshal json")
+			}
+			param = withValidators(param, before)
+		}
+	}
+
+	statusCode, header, b, err := c.doConditionalRequest(param)
+	if err != nil {
+		return &SVG{}, err
+	}
+
+	if statusCode == http.StatusNotModified {
+		if before == nil {
+			return &SVG{}, errors.New("failed to call API: not modified without a previous response")
+		}
+		before.Unchanged = true
+		return before, nil
+	}
+
+	svg := &SVG{Body: string(b), ETag: header.Get(eTag), LastModified: header.Get(lastModified)}
+	if validators != nil && (svg.ETag != "" || svg.LastModified != "") {
+		encoded, err := json.Marshal(svg)
+		if err != nil {
+			return &SVG{}, errors.Wrap(err, "failed to marshal json")
+		}
+		validators.Set(key, encoded, 24*time.Hour)
+	}
+	return svg, nil
+}
+
+// svgValidators returns the cache of the SVGs received with validators, or nil if c is nil.
+func (c *Client) svgValidators() *LRUCache {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.validators == nil {
+		c.validators = NewLRUCache(maxSVGValidators)
+	}
+	return c.validators
+}
+
+func withValidators(param *requestParameter, svg *SVG) *requestParameter {
+	header := map[string]string{}
+	for k, v := range param.Header {
+		header[k] = v
+	}
+	if svg.ETag != "" {
+		header[ifNoneMatch] = svg.ETag
+	}
+	if svg.LastModified != "" {
+		header[ifModifiedSince] = svg.LastModified
+	}
+
+	return &requestParameter{Operation: param.Operation, Method: param.Method, URL: param.URL, Header: header, Body: param.Body}
+}
@@ -0,0 +1,71 @@
+package pixela
+
+import (
+	"net/http"
+	"testing"
+)
+
+func newConditionalMock(requests *[]http.Header) *httpClientMock {
+	return &httpClientMock{
+		header: http.Header{"Etag": []string{`"v1"`}, "Last-Modified": []string{"Sat, 15 Sep 2018 00:00:00 GMT"}},
+		handler: func(req *http.Request) (int, []byte) {
+			*requests = append(*requests, req.Header)
+			if req.Header.Get(ifNoneMatch) == `"v1"` {
+				return http.StatusNotModified, []byte{}
+			}
+			return http.StatusOK, []byte(`<svg></svg>`)
+		},
+	}
+}
+
+func TestGraphGetSVGConditional(t *testing.T) {
+	var requests []http.Header
+	clientMock = newConditionalMock(&requests)
+	client := NewClient(userName, token)
+
+	svg, err := client.Graph(graphID).GetSVGConditional("20180915", ModeShort)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect := SVG{Body: `<svg></svg>`, ETag: `"v1"`, LastModified: "Sat, 15 Sep 2018 00:00:00 GMT"}
+	if *svg != expect {
+		t.Errorf("got: %v\nwant: %v", *svg, expect)
+	}
+
+	svg, err = client.Graph(graphID).GetSVGConditional("20180915", ModeShort)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect.Unchanged = true
+	if *svg != expect {
+		t.Errorf("got: %v\nwant: %v", *svg, expect)
+	}
+	if requests[1].Get(ifModifiedSince) != expect.LastModified {
+		t.Errorf("%s: %s\nwant: %s", ifModifiedSince, requests[1].Get(ifModifiedSince), expect.LastModified)
+	}
+
+	// Another date is a different SVG.
+	svg, err = client.Graph(graphID).GetSVGConditional("20180916", ModeShort)
+	if err != nil || svg.Unchanged {
+		t.Errorf("got: %v, %v\nwant: changed", svg, err)
+	}
+}
+
+func TestGraphGetSVGNotModified(t *testing.T) {
+	var requests []http.Header
+	clientMock = newConditionalMock(&requests)
+	client := NewClient(userName, token)
+
+	for i := 0; i < 2; i++ {
+		svg, err := client.Graph(graphID).GetSVG("20180915", ModeShort)
+		if err != nil {
+			t.Fatalf("got: %v\nwant: nil", err)
+		}
+		if svg != `<svg></svg>` {
+			t.Errorf("got: %s\nwant: <svg></svg>", svg)
+		}
+	}
+	if len(requests) != 2 || requests[1].Get(ifNoneMatch) != `"v1"` {
+		t.Errorf("got: %v\nwant: 2 requests, the last with %s", requests, ifNoneMatch)
+	}
+}
@@ -0,0 +1,373 @@
+package pixela
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Credentials is the user name and the token to call the Pixela API.
+type Credentials struct {
+	UserName string `json:"username"`
+	Token    string `json:"token"`
+	// PendingToken is the token being rotated to.
+	// It is set while the token rotation is in progress, so either Token or PendingToken is valid after a crash.
+	PendingToken string `json:"pendingToken,omitempty"`
+}
+
+// CredentialsProvider provides the credentials of a Client.
+type CredentialsProvider interface {
+	Retrieve() (*Credentials, error)
+}
+
+// CredentialsStore is a CredentialsProvider that can persist the credentials.
+// Client.UpdateUser persists the rotated token through it.
+type CredentialsStore interface {
+	CredentialsProvider
+	Store(credentials *Credentials) error
+}
+
+// NewClientWithCredentials returns a new Client instance with the credentials retrieved from the provider.
+// If the provider is a CredentialsStore, the token rotated by UpdateUser is persisted through it.
+func NewClientWithCredentials(provider CredentialsProvider) (*Client, error) {
+	credentials, err := provider.Retrieve()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve credentials")
+	}
+
+	c := NewClient(credentials.UserName, credentials.Token)
+	c.credentials = provider
+	return c, nil
+}
+
+// Environment variables read by EnvCredentials.
+const (
+	EnvUserName = "PIXELA_USER_NAME"
+	EnvToken    = "PIXELA_TOKEN"
+)
+
+// EnvCredentials provides the credentials from the PIXELA_USER_NAME and PIXELA_TOKEN environment variables.
+// It can not persist the credentials.
+type EnvCredentials struct{}
+
+// Retrieve returns the credentials read from the environment variables.
+func (EnvCredentials) Retrieve() (*Credentials, error) {
+	credentials := &Credentials{UserName: os.Getenv(EnvUserName), Token: os.Getenv(EnvToken)}
+	if credentials.UserName == "" || credentials.Token == "" {
+		return nil, errors.Errorf("%s and %s must be set", EnvUserName, EnvToken)
+	}
+	return credentials, nil
+}
+
+// EnvProfile is the environment variable to select the profile of FileCredentials.
+const EnvProfile = "PIXELA_PROFILE"
+
+// FileCredentials provides the credentials from a shared credentials file with named profiles.
+// The file is formatted as follows.
+//
+//	[default]
+//	username = YOUR_NAME
+//	token = YOUR_TOKEN
+//
+//	[work]
+//	username = ANOTHER_NAME
+//	token = ANOTHER_TOKEN
+type FileCredentials struct {
+	// Path is the path to the file. If empty, $HOME/.pixela/credentials is used.
+	Path string
+	// Profile is the profile name. If empty, PIXELA_PROFILE or "default" is used.
+	Profile string
+}
+
+func (f *FileCredentials) path() (string, error) {
+	if f.Path != "" {
+		return f.Path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get home directory")
+	}
+	return filepath.Join(home, ".pixela", "credentials"), nil
+}
+
+func (f *FileCredentials) profile() string {
+	if f.Profile != "" {
+		return f.Profile
+	}
+	if p := os.Getenv(EnvProfile); p != "" {
+		return p
+	}
+	return "default"
+}
+
+// Retrieve returns the credentials of the profile.
+func (f *FileCredentials) Retrieve() (*Credentials, error) {
+	path, err := f.path()
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read credentials file")
+	}
+
+	profile := f.profile()
+	for _, s := range parseProfiles(b) {
+		if s.name != profile {
+			continue
+		}
+		credentials := &Credentials{
+			UserName:     s.values["username"],
+			Token:        s.values["token"],
+			PendingToken: s.values["pending_token"],
+		}
+		if credentials.UserName == "" || credentials.Token == "" {
+			return nil, errors.Errorf("username and token must be set in profile: %s", profile)
+		}
+		return credentials, nil
+	}
+	return nil, errors.Errorf("profile not found: %s", profile)
+}
+
+// Store writes the credentials to the profile atomically.
+// The other profiles in the file are kept as they are.
+func (f *FileCredentials) Store(credentials *Credentials) error {
+	path, err := f.path()
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil && os.IsNotExist(err) == false {
+		return errors.Wrap(err, "failed to read credentials file")
+	}
+
+	var buf bytes.Buffer
+	profile := f.profile()
+	stored := false
+	for _, s := range parseProfiles(b) {
+		if s.name != profile {
+			buf.WriteString(s.raw)
+			continue
+		}
+		writeProfile(&buf, profile, credentials)
+		stored = true
+	}
+	if stored == false {
+		if buf.Len() > 0 && bytes.HasSuffix(buf.Bytes(), []byte("\n\n")) == false {
+			buf.WriteString("\n")
+		}
+		writeProfile(&buf, profile, credentials)
+	}
+
+	return writeFileAtomic(path, buf.Bytes(), 0600)
+}
+
+type profileSection struct {
+	name   string
+	values map[string]string
+	raw    string
+}
+
+// parseProfiles splits the file into sections.
+// The lines before the first section are returned as a section without name.
+func parseProfiles(b []byte) []profileSection {
+	sections := []profileSection{{values: map[string]string{}}}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			sections = append(sections, profileSection{name: name, values: map[string]string{}})
+		}
+
+		s := &sections[len(sections)-1]
+		s.raw += line + "\n"
+		if kv := strings.SplitN(trimmed, "=", 2); len(kv) == 2 && strings.HasPrefix(trimmed, "#") == false && strings.HasPrefix(trimmed, ";") == false {
+			s.values[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return sections
+}
+
+func writeProfile(buf *bytes.Buffer, profile string, credentials *Credentials) {
+	fmt.Fprintf(buf, "[%s]\n", profile)
+	fmt.Fprintf(buf, "username = %s\n", credentials.UserName)
+	fmt.Fprintf(buf, "token = %s\n", credentials.Token)
+	if credentials.PendingToken != "" {
+		fmt.Fprintf(buf, "pending_token = %s\n", credentials.PendingToken)
+	}
+	buf.WriteString("\n")
+}
+
+// KeyringCredentials provides the credentials from a file-based keyring.
+// Each profile is stored in its own file encrypted with AES-256-GCM by a key derived from the passphrase.
+type KeyringCredentials struct {
+	// Dir is the directory of the keyring. If empty, $HOME/.pixela/keyring is used.
+	Dir string
+	// Profile is the profile name. If empty, PIXELA_PROFILE or "default" is used.
+	Profile string
+	// Passphrase returns the passphrase to encrypt and decrypt the credentials.
+	Passphrase func() (string, error)
+}
+
+const (
+	keyringIterations = 100000
+	keyringSaltSize   = 16
+	keyringKeySize    = 32
+)
+
+type keyringItem struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (k *KeyringCredentials) path() (string, error) {
+	dir := k.Dir
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get home directory")
+		}
+		dir = filepath.Join(home, ".pixela", "keyring")
+	}
+
+	profile := (&FileCredentials{Profile: k.Profile}).profile()
+	if strings.ContainsAny(profile, `/\`) || profile == "." || profile == ".." {
+		return "", errors.Errorf("invalid profile: %s", profile)
+	}
+	return filepath.Join(dir, profile+".json"), nil
+}
+
+func (k *KeyringCredentials) aead(salt []byte) (cipher.AEAD, error) {
+	if k.Passphrase == nil {
+		return nil, errors.New("passphrase is not set")
+	}
+	passphrase, err := k.Passphrase()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get passphrase")
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, keyringIterations, keyringKeySize, sha256.New))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// Retrieve decrypts and returns the credentials of the profile.
+func (k *KeyringCredentials) Retrieve() (*Credentials, error) {
+	path, err := k.path()
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read keyring")
+	}
+
+	var item keyringItem
+	if err := json.Unmarshal(b, &item); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal json")
+	}
+	aead, err := k.aead(item.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, item.Nonce, item.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt keyring: wrong passphrase or broken file")
+	}
+
+	var credentials Credentials
+	if err := json.Unmarshal(plaintext, &credentials); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal json")
+	}
+	return &credentials, nil
+}
+
+// Store encrypts and writes the credentials of the profile atomically.
+func (k *KeyringCredentials) Store(credentials *Credentials) error {
+	path, err := k.path()
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal json")
+	}
+
+	item := keyringItem{Salt: make([]byte, keyringSaltSize)}
+	if _, err := rand.Read(item.Salt); err != nil {
+		return errors.Wrap(err, "failed to generate salt")
+	}
+	aead, err := k.aead(item.Salt)
+	if err != nil {
+		return err
+	}
+	item.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(item.Nonce); err != nil {
+		return errors.Wrap(err, "failed to generate nonce")
+	}
+	item.Ciphertext = aead.Seal(nil, item.Nonce, plaintext, nil)
+
+	b, err := json.Marshal(&item)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal json")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "failed to create keyring directory")
+	}
+	return writeFileAtomic(path, b, 0600)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to path,
+// so path has either the old or the new content even if the process crashes.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to write temporary file")
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to sync temporary file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to close temporary file")
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return errors.Wrap(err, "failed to change mode of temporary file")
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return errors.Wrap(err, "failed to rename temporary file")
+	}
+
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
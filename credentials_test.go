@@ -0,0 +1,175 @@
+package pixela
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pixela")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestEnvCredentials(t *testing.T) {
+	os.Setenv(EnvUserName, userName)
+	os.Setenv(EnvToken, token)
+	defer os.Unsetenv(EnvUserName)
+	defer os.Unsetenv(EnvToken)
+
+	credentials, err := EnvCredentials{}.Retrieve()
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect := &Credentials{UserName: userName, Token: token}
+	if *credentials != *expect {
+		t.Errorf("got: %v\nwant: %v", credentials, expect)
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials")
+	s := "# comment\n[default]\nusername = user\ntoken = token\n\n[work]\nusername = worker\ntoken = work-token\n"
+	if err := ioutil.WriteFile(path, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	work := &FileCredentials{Path: path, Profile: "work"}
+	credentials, err := work.Retrieve()
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect := &Credentials{UserName: "worker", Token: "work-token"}
+	if *credentials != *expect {
+		t.Errorf("got: %v\nwant: %v", credentials, expect)
+	}
+
+	stored := &Credentials{UserName: "user", Token: "token", PendingToken: "new-token"}
+	if err := (&FileCredentials{Path: path, Profile: "default"}).Store(stored); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectFile := "# comment\n[default]\nusername = user\ntoken = token\npending_token = new-token\n\n[work]\nusername = worker\ntoken = work-token\n"
+	if string(b) != expectFile {
+		t.Errorf("got: %q\nwant: %q", string(b), expectFile)
+	}
+}
+
+func TestFileCredentialsNewProfile(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	f := &FileCredentials{Path: filepath.Join(dir, "credentials"), Profile: "new"}
+	if err := f.Store(&Credentials{UserName: userName, Token: token}); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	credentials, err := f.Retrieve()
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect := &Credentials{UserName: userName, Token: token}
+	if *credentials != *expect {
+		t.Errorf("got: %v\nwant: %v", credentials, expect)
+	}
+
+	if _, err := (&FileCredentials{Path: f.Path, Profile: "missing"}).Retrieve(); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestKeyringCredentials(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	passphrase := func() (string, error) { return "passphrase", nil }
+	k := &KeyringCredentials{Dir: dir, Profile: "default", Passphrase: passphrase}
+	expect := &Credentials{UserName: userName, Token: token, PendingToken: "new-token"}
+	if err := k.Store(expect); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "default.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "new-token") {
+		t.Errorf("keyring is not encrypted: %s", string(b))
+	}
+
+	credentials, err := k.Retrieve()
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	if *credentials != *expect {
+		t.Errorf("got: %v\nwant: %v", credentials, expect)
+	}
+
+	wrong := &KeyringCredentials{Dir: dir, Profile: "default", Passphrase: func() (string, error) { return "wrong", nil }}
+	if _, err := wrong.Retrieve(); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+type credentialsStoreMock struct {
+	credentials *Credentials
+	stored      []Credentials
+}
+
+func (s *credentialsStoreMock) Retrieve() (*Credentials, error) {
+	return s.credentials, nil
+}
+
+func (s *credentialsStoreMock) Store(credentials *Credentials) error {
+	s.stored = append(s.stored, *credentials)
+	return nil
+}
+
+func TestClientUpdateUserStoresToken(t *testing.T) {
+	clientMock = newOKMock()
+
+	store := &credentialsStoreMock{credentials: &Credentials{UserName: userName, Token: token}}
+	client, err := NewClientWithCredentials(store)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	result, err := client.UpdateUser("new-token", "")
+	testSuccess(t, result, err)
+
+	expect := []Credentials{
+		{UserName: userName, Token: token, PendingToken: "new-token"},
+		{UserName: userName, Token: "new-token"},
+	}
+	if reflect.DeepEqual(store.stored, expect) == false {
+		t.Errorf("got: %v\nwant: %v", store.stored, expect)
+	}
+}
+
+func TestClientUpdateUserFailKeepsToken(t *testing.T) {
+	clientMock = newAPIFailedMock()
+
+	store := &credentialsStoreMock{credentials: &Credentials{UserName: userName, Token: token}}
+	client, err := NewClientWithCredentials(store)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	result, err := client.UpdateUser("new-token", "")
+	testAPIFailedResult(t, result, err)
+
+	expect := Credentials{UserName: userName, Token: token}
+	if store.stored[len(store.stored)-1] != expect {
+		t.Errorf("got: %v\nwant: %v", store.stored[len(store.stored)-1], expect)
+	}
+}
@@ -0,0 +1,203 @@
+package pixela
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FormatCurl is the format of DryRun.Export that writes each request as a curl command.
+// The token is read from the PIXELA_TOKEN environment variable when the commands are run,
+// and each webhook hash from a PIXELA_WEBHOOK_<n> environment variable listed at the top of the commands.
+const FormatCurl = "curl"
+
+// envWebhookPrefix is the prefix of the environment variables of the webhook hashes in the curl commands.
+const envWebhookPrefix = "PIXELA_WEBHOOK_"
+
+// dryRunResponse is the response to a request recorded in the dry-run mode.
+const dryRunResponse = `{"message":"Success.","isSuccess":true}`
+
+// DryRun is the mutating requests recorded by a Client in the dry-run mode, in the order they were made.
+// It is safe for concurrent use.
+type DryRun struct {
+	mu       sync.Mutex
+	requests []*requestParameter
+}
+
+// PlannedRequest is a request recorded in a DryRun. Its secrets are masked by Redact.
+type PlannedRequest struct {
+	Operation string            `json:"operation"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Header    map[string]string `json:"header,omitempty"`
+	Body      string            `json:"body,omitempty"`
+}
+
+// EnableDryRun switches the Client to the dry-run mode and returns the DryRun its mutating requests are recorded in.
+// In the dry-run mode, the requests other than GET of the Client and the Graph, Pixel and Webhook returned by it
+// are not sent and succeed with the message "Success.", while GET requests are sent as usual.
+// UpdateUser does not switch the token either, and Webhook.Create returns an empty WebhookHash
+// because no webhook is created.
+// The recorded requests are logged with DryRun set if logging is enabled.
+// It must be called before the Client is used.
+func (c *Client) EnableDryRun() *DryRun {
+	if c.dryRun == nil {
+		c.dryRun = &DryRun{}
+	}
+	return c.dryRun
+}
+
+// recordRequest records the request and passes a successful response to handle if c is in the dry-run mode and
+// the request is not a GET request. It reports whether the request was recorded.
+func (c *Client) recordRequest(param *requestParameter, handle func(resp *http.Response) error) (bool, error) {
+	if c == nil || c.dryRun == nil || param.Method == http.MethodGet {
+		return false, nil
+	}
+
+	c.dryRun.mu.Lock()
+	c.dryRun.requests = append(c.dryRun.requests, param)
+	c.dryRun.mu.Unlock()
+
+	if c.logger != nil {
+		entry := &LogEntry{Operation: param.Operation, Method: param.Method, URL: param.redact(param.URL), DryRun: true}
+		if c.verbose {
+			entry.RequestBody = param.redact(string(param.Body))
+		}
+		c.logger.Log(entry)
+	}
+
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{contentType: []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(dryRunResponse)),
+	}
+	return true, handle(resp)
+}
+
+// inDryRun reports whether the Client is in the dry-run mode.
+func (c *Client) inDryRun() bool {
+	return c.dryRun != nil
+}
+
+// Requests returns the recorded requests.
+func (d *DryRun) Requests() []PlannedRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	requests := make([]PlannedRequest, len(d.requests))
+	for i, param := range d.requests {
+		requests[i] = *plannedRequest(param)
+	}
+	return requests
+}
+
+func plannedRequest(param *requestParameter) *PlannedRequest {
+	redacted := param.redacted()
+	return &PlannedRequest{
+		Operation: redacted.Operation,
+		Method:    redacted.Method,
+		URL:       redacted.URL,
+		Header:    redacted.Header,
+		Body:      string(redacted.Body),
+	}
+}
+
+// Export writes the recorded requests to w in the format, FormatJSON or FormatCurl.
+func (d *DryRun) Export(w io.Writer, format string) error {
+	requests := d.Requests()
+	switch format {
+	case FormatJSON:
+		return errors.Wrap(json.NewEncoder(w).Encode(requests), "failed to write json")
+	case FormatCurl:
+		_, err := io.WriteString(w, d.curlCommands())
+		return errors.Wrap(err, "failed to write curl commands")
+	default:
+		return errors.Errorf("unsupported format: %s", format)
+	}
+}
+
+// curlCommands returns the recorded requests as curl commands.
+// The webhook hashes are numbered in the order they first appear, and the commands using each are listed at the top
+// so that the environment variables can be set.
+func (d *DryRun) curlCommands() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	webhooks := map[string]int{}
+	var uses [][]string
+	var commands bytes.Buffer
+	for i, param := range d.requests {
+		webhook := 0
+		if m := webhookPathPattern.FindStringSubmatch(param.URL); m != nil {
+			webhook = webhooks[m[2]]
+			if webhook == 0 {
+				uses = append(uses, nil)
+				webhook = len(uses)
+				webhooks[m[2]] = webhook
+			}
+			uses[webhook-1] = append(uses[webhook-1], strconv.Itoa(i+1))
+		}
+		commands.WriteString(curlCommand(i+1, plannedRequest(param), webhook))
+	}
+
+	var b bytes.Buffer
+	if len(uses) > 0 {
+		b.WriteString("# Set the webhook hashes before running the commands:\n")
+		for i, commands := range uses {
+			fmt.Fprintf(&b, "#   %s%d: the webhook of the commands %s\n", envWebhookPrefix, i+1, strings.Join(commands, ", "))
+		}
+	}
+	b.Write(commands.Bytes())
+	return b.String()
+}
+
+// curlCommand returns the request as a comment line with its number and operation followed by a curl command.
+// If webhook is not 0, the redacted webhook hash in the URL is replaced by the environment variable of the webhook.
+func curlCommand(number int, request *PlannedRequest, webhook int) string {
+	url := shellQuote(request.URL)
+	if webhook != 0 {
+		parts := strings.SplitN(request.URL, "/webhooks/"+Redacted, 2)
+		url = shellQuote(parts[0]+"/webhooks/") + fmt.Sprintf(`"$%s%d"`, envWebhookPrefix, webhook)
+		if len(parts) == 2 && parts[1] != "" {
+			url += shellQuote(parts[1])
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %d. %s\ncurl -X %s %s", number, request.Operation, request.Method, url)
+
+	keys := make([]string, 0, len(request.Header))
+	for k := range request.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch k {
+		case contentLength:
+			// curl sets it from the body.
+		case userToken:
+			fmt.Fprintf(&b, ` -H "%s: $%s"`, userToken, EnvToken)
+		default:
+			fmt.Fprintf(&b, " -H %s", shellQuote(k+": "+request.Header[k]))
+		}
+	}
+	if request.Body != "" {
+		fmt.Fprintf(&b, " -H %s -d %s", shellQuote(contentType+": application/json"), shellQuote(request.Body))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// shellQuote quotes s in single quotes for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,179 @@
+package pixela
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func newDryRunMock(t *testing.T) *httpClientMock {
+	return &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		if req.Method != http.MethodGet {
+			t.Errorf("got: %s %s\nwant: only GET requests are sent", req.Method, req.URL)
+		}
+		return http.StatusOK, []byte(`{"graphs":[{"id":"graph-id"}]}`)
+	}}
+}
+
+func TestDryRun(t *testing.T) {
+	clientMock = newDryRunMock(t)
+
+	client := NewClient(userName, token)
+	dryRun := client.EnableDryRun()
+
+	result, err := client.Pixel(graphID).Create("20180915", "5", "")
+	testSuccess(t, result, err)
+	result, err = client.Pixel(graphID).Increment()
+	testSuccess(t, result, err)
+	result, err = client.Graph(graphID).Delete()
+	testSuccess(t, result, err)
+	result, err = client.Webhook().Invoke(webhookHash)
+	testSuccess(t, result, err)
+	result, err = client.DeleteUser()
+	testSuccess(t, result, err)
+
+	definitions, err := client.Graph("").GetAll()
+	if err != nil || len(definitions.Graphs) != 1 {
+		t.Errorf("got: %v, %v\nwant: the graphs read from the API", definitions, err)
+	}
+
+	var operations []string
+	for _, request := range dryRun.Requests() {
+		operations = append(operations, request.Operation)
+	}
+	expect := []string{"Pixel.Create", "Pixel.Increment", "Graph.Delete", "Webhook.Invoke", "Client.DeleteUser"}
+	if reflect.DeepEqual(operations, expect) == false {
+		t.Errorf("got: %v\nwant: %v", operations, expect)
+	}
+}
+
+func TestDryRunUpdateUser(t *testing.T) {
+	clientMock = newDryRunMock(t)
+
+	client := NewClient(userName, token)
+	dryRun := client.EnableDryRun()
+	result, err := client.UpdateUser(newToken, "")
+	testSuccess(t, result, err)
+
+	if client.CurrentToken() != token {
+		t.Errorf("got: %s\nwant: %s", client.CurrentToken(), token)
+	}
+	requests := dryRun.Requests()
+	if len(requests) != 1 || requests[0].Body != `{"newToken":"[REDACTED]"}` {
+		t.Errorf("got: %+v\nwant: the update with newToken redacted", requests)
+	}
+}
+
+func TestDryRunExportJSON(t *testing.T) {
+	clientMock = newDryRunMock(t)
+
+	client := NewClient(userName, token)
+	dryRun := client.EnableDryRun()
+	client.Webhook().Delete(webhookHash)
+
+	var buf bytes.Buffer
+	if err := dryRun.Export(&buf, FormatJSON); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	testRedacted(t, buf.String())
+
+	var requests []PlannedRequest
+	if err := json.Unmarshal(buf.Bytes(), &requests); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect := []PlannedRequest{{
+		Operation: "Webhook.Delete",
+		Method:    http.MethodDelete,
+		URL:       APIBaseURL + "/users/user/webhooks/" + Redacted,
+		Header:    map[string]string{userToken: Redacted},
+	}}
+	if reflect.DeepEqual(requests, expect) == false {
+		t.Errorf("got: %+v\nwant: %+v", requests, expect)
+	}
+}
+
+func TestDryRunExportCurl(t *testing.T) {
+	clientMock = newDryRunMock(t)
+
+	client := NewClient(userName, token)
+	dryRun := client.EnableDryRun()
+	client.Pixel(graphID).Increment()
+	client.Pixel(graphID).Update("20180915", "5", `{"memo":"it's"}`)
+
+	var buf bytes.Buffer
+	if err := dryRun.Export(&buf, FormatCurl); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect := `# 1. Pixel.Increment
+curl -X PUT 'https://pixe.la/v1/users/user/graphs/graph-id/increment' -H "X-USER-TOKEN: $PIXELA_TOKEN"
+# 2. Pixel.Update
+curl -X PUT 'https://pixe.la/v1/users/user/graphs/graph-id/20180915' -H "X-USER-TOKEN: $PIXELA_TOKEN" -H 'Content-Type: application/json' -d '{"quantity":"5","optionalData":"{\"memo\":\"it'\''s\"}"}'
+`
+	if buf.String() != expect {
+		t.Errorf("got: %s\nwant: %s", buf.String(), expect)
+	}
+}
+
+func TestDryRunExportCurlWebhook(t *testing.T) {
+	clientMock = newDryRunMock(t)
+
+	client := NewClient(userName, token)
+	dryRun := client.EnableDryRun()
+	client.Webhook().Invoke("hash-a")
+	client.Webhook().Invoke("hash-b")
+	client.Webhook().Delete("hash-a")
+
+	var buf bytes.Buffer
+	if err := dryRun.Export(&buf, FormatCurl); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect := `# Set the webhook hashes before running the commands:
+#   PIXELA_WEBHOOK_1: the webhook of the commands 1, 3
+#   PIXELA_WEBHOOK_2: the webhook of the commands 2
+# 1. Webhook.Invoke
+curl -X POST 'https://pixe.la/v1/users/user/webhooks/'"$PIXELA_WEBHOOK_1"
+# 2. Webhook.Invoke
+curl -X POST 'https://pixe.la/v1/users/user/webhooks/'"$PIXELA_WEBHOOK_2"
+# 3. Webhook.Delete
+curl -X DELETE 'https://pixe.la/v1/users/user/webhooks/'"$PIXELA_WEBHOOK_1" -H "X-USER-TOKEN: $PIXELA_TOKEN"
+`
+	if buf.String() != expect {
+		t.Errorf("got: %s\nwant: %s", buf.String(), expect)
+	}
+}
+
+func TestDryRunExportUnsupportedFormat(t *testing.T) {
+	dryRun := NewClient(userName, token).EnableDryRun()
+	if err := dryRun.Export(&bytes.Buffer{}, FormatCSV); err == nil {
+		t.Errorf("got: nil\nwant: unsupported format")
+	}
+}
+
+func TestDryRunLogging(t *testing.T) {
+	clientMock = newDryRunMock(t)
+
+	recorder := &logRecorder{}
+	client := NewClient(userName, token)
+	client.EnableLogging(recorder, true)
+	client.EnableDryRun()
+	client.Webhook().Invoke(webhookHash)
+	client.Graph("").GetAll()
+
+	if len(recorder.entries) != 2 {
+		t.Fatalf("got: %d entries\nwant: 2", len(recorder.entries))
+	}
+	expect := &LogEntry{
+		Operation: "Webhook.Invoke",
+		Method:    http.MethodPost,
+		URL:       APIBaseURL + "/users/user/webhooks/" + Redacted,
+		DryRun:    true,
+	}
+	if got := recorder.entries[0]; *got != *expect {
+		t.Errorf("got: %+v\nwant: %+v", got, expect)
+	}
+	if got := recorder.entries[1]; got.DryRun || got.StatusCode != http.StatusOK {
+		t.Errorf("got: %+v\nwant: the GET request sent", got)
+	}
+}
@@ -0,0 +1,430 @@
+package pixela
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Specify the format of the data written by Export and read by Import.
+// csv writes the graph definition as a comment line followed by a date,quantity,optionalData table.
+// jsonl writes the graph definition on the first line and one pixel per line.
+// json writes the graph definition and the pixels as a single document.
+const (
+	FormatCSV       = "csv"
+	FormatJSONLines = "jsonl"
+	FormatJSON      = "json"
+)
+
+const dateFormat = "20060102"
+
+// maxPixelDatesWindows is the number of 365 days periods Export walks back at most.
+const maxPixelDatesWindows = 100
+
+// PixelValue is the quantity registered as a "Pixel" on the date.
+type PixelValue struct {
+	Date         string `json:"date"`
+	Quantity     string `json:"quantity"`
+	OptionalData string `json:"optionalData,omitempty"`
+}
+
+// GraphExport is the graph definition and all of its pixels.
+type GraphExport struct {
+	Graph  GraphDefinition `json:"graph"`
+	Pixels []PixelValue    `json:"pixels"`
+}
+
+// Export writes the graph definition and all pixels registered in the graph to w in the specified format.
+func (g *Graph) Export(ctx context.Context, w io.Writer, format string) error {
+	return ExportGraph(ctx, g, g.pixel(), g.GraphID, w, format)
+}
+
+// ExportGraph is Graph.Export for any GraphService and PixelService of the graph, such as fakes.
+func ExportGraph(ctx context.Context, graph GraphService, pixel PixelService, graphID string, w io.Writer, format string) error {
+	definition, err := graphDefinition(graph, graphID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get graph definition")
+	}
+
+	pixels, err := pixelValues(ctx, graph, pixel)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get pixels")
+	}
+
+	return writeGraphExport(w, format, &GraphExport{Graph: *definition, Pixels: pixels})
+}
+
+func (g *Graph) pixel() *Pixel {
+	return &Pixel{UserName: g.UserName, Token: g.Token, GraphID: g.GraphID, client: g.client, state: g.state}
+}
+
+func (g *Graph) definition() (*GraphDefinition, error) {
+	return graphDefinition(g, g.GraphID)
+}
+
+func graphDefinition(graph GraphService, graphID string) (*GraphDefinition, error) {
+	definitions, err := graph.GetAll()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get all graph definitions")
+	}
+	if definitions.IsSuccess == false {
+		return nil, errors.Errorf("failed to get all graph definitions: %s", definitions.Message)
+	}
+
+	for i := range definitions.Graphs {
+		if definitions.Graphs[i].ID == graphID {
+			return &definitions.Graphs[i], nil
+		}
+	}
+	return nil, errors.Errorf("graph not found: %s", graphID)
+}
+
+// pixelDates collects the dates of all pixels registered in the graph.
+// GetPixelDates returns at most 365 days, so it walks back from tomorrow until the collected dates reach the total pixels count.
+func pixelDates(ctx context.Context, graph GraphService) ([]string, error) {
+	stats, err := graph.Stats()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get graph stats")
+	}
+	if stats.IsSuccess == false {
+		return nil, errors.Errorf("failed to get graph stats: %s", stats.Message)
+	}
+
+	var dates []string
+	to := time.Now().UTC().AddDate(0, 0, 1)
+	for i := 0; i < maxPixelDatesWindows && len(dates) < stats.TotalPixelsCount; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		from := to.AddDate(0, 0, -364)
+		pixels, err := graph.GetPixelDates(from.Format(dateFormat), to.Format(dateFormat))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get pixel dates")
+		}
+		if pixels.IsSuccess == false {
+			return nil, errors.Errorf("failed to get pixel dates: %s", pixels.Message)
+		}
+		dates = append(dates, pixels.Pixels...)
+		to = from.AddDate(0, 0, -1)
+	}
+
+	sort.Strings(dates)
+	return dates, nil
+}
+
+func (g *Graph) pixelValues(ctx context.Context) ([]PixelValue, error) {
+	return pixelValues(ctx, g, g.pixel())
+}
+
+func pixelValues(ctx context.Context, graph GraphService, pixel PixelService) ([]PixelValue, error) {
+	dates, err := pixelDates(ctx, graph)
+	if err != nil {
+		return nil, err
+	}
+	return getPixelValues(ctx, pixel, dates)
+}
+
+// getPixelValues gets the pixels of the dates.
+func getPixelValues(ctx context.Context, pixel PixelService, dates []string) ([]PixelValue, error) {
+	values := make([]PixelValue, 0, len(dates))
+	for _, date := range dates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		quantity, err := pixel.Get(date)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get pixel: %s", date)
+		}
+		if quantity.IsSuccess == false {
+			return nil, errors.Errorf("failed to get pixel: %s: %s", date, quantity.Message)
+		}
+		values = append(values, PixelValue{Date: date, Quantity: quantity.Quantity, OptionalData: quantity.OptionalData})
+	}
+	return values, nil
+}
+
+var csvHeader = []string{"date", "quantity", "optionalData"}
+
+func writeGraphExport(w io.Writer, format string, export *GraphExport) error {
+	switch format {
+	case FormatCSV:
+		b, err := json.Marshal(&export.Graph)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal json")
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n", b); err != nil {
+			return errors.Wrap(err, "failed to write graph definition")
+		}
+
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return errors.Wrap(err, "failed to write csv")
+		}
+		for _, p := range export.Pixels {
+			if err := cw.Write([]string{p.Date, p.Quantity, p.OptionalData}); err != nil {
+				return errors.Wrap(err, "failed to write csv")
+			}
+		}
+		cw.Flush()
+		return errors.Wrap(cw.Error(), "failed to write csv")
+	case FormatJSONLines:
+		enc := json.NewEncoder(w)
+		header := struct {
+			Graph *GraphDefinition `json:"graph"`
+		}{Graph: &export.Graph}
+		if err := enc.Encode(&header); err != nil {
+			return errors.Wrap(err, "failed to write json")
+		}
+		for i := range export.Pixels {
+			if err := enc.Encode(&export.Pixels[i]); err != nil {
+				return errors.Wrap(err, "failed to write json")
+			}
+		}
+		return nil
+	case FormatJSON:
+		if export.Pixels == nil {
+			export.Pixels = []PixelValue{}
+		}
+		return errors.Wrap(json.NewEncoder(w).Encode(export), "failed to write json")
+	default:
+		return errors.Errorf("unsupported format: %s", format)
+	}
+}
+
+// exportRecord is a line of the jsonl format.
+// The first line has only the graph definition and the following lines have a pixel each.
+type exportRecord struct {
+	Graph *GraphDefinition `json:"graph,omitempty"`
+	PixelValue
+}
+
+func readGraphExport(r io.Reader, format string) (*GraphExport, error) {
+	var export GraphExport
+
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.Comment = '#'
+		cr.FieldsPerRecord = -1
+		for n := 1; ; n++ {
+			record, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read csv")
+			}
+			if n == 1 && record[0] == csvHeader[0] {
+				continue
+			}
+			if len(record) < 2 || len(record) > 3 {
+				return nil, errors.Errorf("record %d: expected date,quantity[,optionalData]", n)
+			}
+			p := PixelValue{Date: record[0], Quantity: record[1]}
+			if len(record) == 3 {
+				p.OptionalData = record[2]
+			}
+			export.Pixels = append(export.Pixels, p)
+		}
+	case FormatJSONLines:
+		dec := json.NewDecoder(r)
+		for {
+			var record exportRecord
+			err := dec.Decode(&record)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to unmarshal json")
+			}
+			if record.Graph != nil {
+				export.Graph = *record.Graph
+				continue
+			}
+			export.Pixels = append(export.Pixels, record.PixelValue)
+		}
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&export); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal json")
+		}
+	default:
+		return nil, errors.Errorf("unsupported format: %s", format)
+	}
+
+	return &export, nil
+}
+
+// ImportDiff is the set of changes Import applies to the graph.
+type ImportDiff struct {
+	Create    []PixelValue
+	Update    []PixelChange
+	Unchanged []PixelValue
+}
+
+// PixelChange is a pixel whose quantity or optional data is updated.
+type PixelChange struct {
+	Before PixelValue
+	After  PixelValue
+}
+
+// Import reads pixels written by Export from r and registers them in the graph.
+// Every pixel is validated against the graph type before anything is sent.
+// Pixels that do not exist yet are created, and pixels that differ are updated.
+// If dryRun is true, Import only returns the changes without applying them.
+func (g *Graph) Import(ctx context.Context, r io.Reader, format string, dryRun bool) (*ImportDiff, error) {
+	return ImportGraph(ctx, g, g.pixel(), g.GraphID, r, format, dryRun)
+}
+
+// ImportGraph is Graph.Import for any GraphService and PixelService of the graph, such as fakes.
+func ImportGraph(ctx context.Context, graph GraphService, pixel PixelService, graphID string, r io.Reader, format string, dryRun bool) (*ImportDiff, error) {
+	export, err := readGraphExport(r, format)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read pixels")
+	}
+
+	definition, err := graphDefinition(graph, graphID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get graph definition")
+	}
+
+	return importPixels(ctx, pixel, export.Pixels, definition.Type, dryRun)
+}
+
+func (g *Graph) importPixels(ctx context.Context, values []PixelValue, quantityType string, dryRun bool) (*ImportDiff, error) {
+	return importPixels(ctx, g.pixel(), values, quantityType, dryRun)
+}
+
+func importPixels(ctx context.Context, pixel PixelService, values []PixelValue, quantityType string, dryRun bool) (*ImportDiff, error) {
+	if err := validatePixelValues(values, quantityType); err != nil {
+		return nil, err
+	}
+
+	diff, err := diffPixels(ctx, pixel, values)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return diff, nil
+	}
+
+	for _, p := range diff.Create {
+		if err := ctx.Err(); err != nil {
+			return diff, err
+		}
+		result, err := pixel.Create(p.Date, p.Quantity, p.OptionalData)
+		if err := checkResult(result, err); err != nil {
+			return diff, errors.Wrapf(err, "failed to create pixel: %s", p.Date)
+		}
+	}
+	for _, c := range diff.Update {
+		if err := ctx.Err(); err != nil {
+			return diff, err
+		}
+		result, err := pixel.Update(c.After.Date, c.After.Quantity, c.After.OptionalData)
+		if err := checkResult(result, err); err != nil {
+			return diff, errors.Wrapf(err, "failed to update pixel: %s", c.After.Date)
+		}
+	}
+
+	return diff, nil
+}
+
+func diffPixels(ctx context.Context, pixel PixelService, values []PixelValue) (*ImportDiff, error) {
+	diff := &ImportDiff{}
+	for _, v := range values {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		current, err := pixel.Get(v.Date)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get pixel: %s", v.Date)
+		}
+		if current.IsSuccess == false {
+			// Only a missing pixel is created; the other failures such as "503 Please retry" must not be.
+			if current.StatusCode != http.StatusNotFound {
+				return nil, errors.Errorf("failed to get pixel: %s: %s", v.Date, current.Message)
+			}
+			diff.Create = append(diff.Create, v)
+			continue
+		}
+
+		before := PixelValue{Date: v.Date, Quantity: current.Quantity, OptionalData: current.OptionalData}
+		if equalQuantity(before.Quantity, v.Quantity) && before.OptionalData == v.OptionalData {
+			diff.Unchanged = append(diff.Unchanged, v)
+			continue
+		}
+		diff.Update = append(diff.Update, PixelChange{Before: before, After: v})
+	}
+	return diff, nil
+}
+
+func validatePixelValues(values []PixelValue, quantityType string) error {
+	dates := make(map[string]bool, len(values))
+	for i, v := range values {
+		if err := ValidateDate(v.Date); err != nil {
+			return errors.Wrapf(err, "pixel %d", i+1)
+		}
+		if dates[v.Date] {
+			return errors.Errorf("pixel %d: duplicate date: %s", i+1, v.Date)
+		}
+		dates[v.Date] = true
+
+		if err := validateQuantity(v.Quantity, quantityType); err != nil {
+			return errors.Wrapf(err, "pixel %d", i+1)
+		}
+	}
+	return nil
+}
+
+func validateQuantity(quantity, quantityType string) error {
+	switch quantityType {
+	case TypeInt:
+		if _, err := strconv.Atoi(quantity); err != nil {
+			return errors.Errorf("quantity is not int: %q", quantity)
+		}
+	case TypeFloat:
+		if _, err := strconv.ParseFloat(quantity, 64); err != nil {
+			return errors.Errorf("quantity is not float: %q", quantity)
+		}
+	default:
+		return errors.Errorf("unsupported graph type: %s", quantityType)
+	}
+	return nil
+}
+
+func equalQuantity(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, err := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	if err != nil {
+		return false
+	}
+	y, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if err != nil {
+		return false
+	}
+	return x == y
+}
+
+func checkResult(result *Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if result.IsSuccess == false {
+		return errors.New(result.Message)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package pixela
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testGraphDefinitions = `{"graphs":[{"id":"graph-id","name":"graph-name","unit":"commit","type":"int","color":"shibafu","timezone":"Asia/Tokyo","purgeCacheURLs":[],"selfSufficient":"none","isSecret":false,"publishOptionalData":false}]}`
+
+func newExportMock(pixels map[string]string, requests *[]string) *httpClientMock {
+	return &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		*requests = append(*requests, req.Method+" "+req.URL.Path)
+
+		prefix := "/v1/users/" + userName + "/graphs"
+		path := strings.TrimPrefix(req.URL.Path, prefix)
+		switch {
+		case req.Method == http.MethodGet && path == "":
+			return http.StatusOK, []byte(testGraphDefinitions)
+		case req.Method == http.MethodGet && path == "/"+graphID+"/stats":
+			return http.StatusOK, []byte(`{"totalPixelsCount":` + strconv.Itoa(len(pixels)) + `}`)
+		case req.Method == http.MethodGet && path == "/"+graphID+"/pixels":
+			return http.StatusOK, []byte(`{"pixels":["20180916","20180915"]}`)
+		case req.Method == http.MethodGet:
+			date := strings.TrimPrefix(path, "/"+graphID+"/")
+			if q, ok := pixels[date]; ok {
+				return http.StatusOK, []byte(`{"quantity":"` + q + `","optionalData":""}`)
+			}
+			return http.StatusNotFound, []byte(`{"message":"Specified pixel not found.","isSuccess":false}`)
+		default:
+			return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+		}
+	}}
+}
+
+func TestGraphExport(t *testing.T) {
+	var requests []string
+	clientMock = newExportMock(map[string]string{"20180915": "5", "20180916": "3"}, &requests)
+
+	client := Client{UserName: userName, Token: token}
+	var buf bytes.Buffer
+	if err := client.Graph(graphID).Export(context.Background(), &buf, FormatJSONLines); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expect := []string{
+		`{"graph":{"id":"graph-id","name":"graph-name","unit":"commit","type":"int","color":"shibafu","timezone":"Asia/Tokyo","purgeCacheURLs":[],"selfSufficient":"none","isSecret":false,"publishOptionalData":false}}`,
+		`{"date":"20180915","quantity":"5"}`,
+		`{"date":"20180916","quantity":"3"}`,
+	}
+	if reflect.DeepEqual(lines, expect) == false {
+		t.Errorf("got: %v\nwant: %v", lines, expect)
+	}
+}
+
+func TestGraphExportUnsupportedFormat(t *testing.T) {
+	var requests []string
+	clientMock = newExportMock(map[string]string{}, &requests)
+
+	client := Client{UserName: userName, Token: token}
+	err := client.Graph(graphID).Export(context.Background(), &bytes.Buffer{}, "xml")
+	if err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestWriteAndReadGraphExport(t *testing.T) {
+	export := &GraphExport{
+		Graph: GraphDefinition{ID: graphID, Type: TypeInt},
+		Pixels: []PixelValue{
+			{Date: "20180915", Quantity: "5", OptionalData: `{"key":"a,b"}`},
+			{Date: "20180916", Quantity: "3"},
+		},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONLines, FormatJSON} {
+		var buf bytes.Buffer
+		if err := writeGraphExport(&buf, format, export); err != nil {
+			t.Fatalf("%s: got: %v\nwant: nil", format, err)
+		}
+		actual, err := readGraphExport(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: got: %v\nwant: nil", format, err)
+		}
+		if reflect.DeepEqual(actual.Pixels, export.Pixels) == false {
+			t.Errorf("%s: got: %v\nwant: %v", format, actual.Pixels, export.Pixels)
+		}
+	}
+}
+
+func TestGraphImportDryRun(t *testing.T) {
+	var requests []string
+	clientMock = newExportMock(map[string]string{"20180915": "5", "20180916": "3"}, &requests)
+
+	in := "date,quantity,optionalData\n20180915,5,\n20180916,4,\n20180917,1,\n"
+	client := Client{UserName: userName, Token: token}
+	diff, err := client.Graph(graphID).Import(context.Background(), strings.NewReader(in), FormatCSV, true)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := &ImportDiff{
+		Create: []PixelValue{{Date: "20180917", Quantity: "1"}},
+		Update: []PixelChange{{
+			Before: PixelValue{Date: "20180916", Quantity: "3"},
+			After:  PixelValue{Date: "20180916", Quantity: "4"},
+		}},
+		Unchanged: []PixelValue{{Date: "20180915", Quantity: "5"}},
+	}
+	if reflect.DeepEqual(diff, expect) == false {
+		t.Errorf("got: %v\nwant: %v", diff, expect)
+	}
+
+	for _, r := range requests {
+		if strings.HasPrefix(r, http.MethodGet) == false {
+			t.Errorf("dry run sent: %s", r)
+		}
+	}
+}
+
+func TestGraphImport(t *testing.T) {
+	var requests []string
+	clientMock = newExportMock(map[string]string{"20180916": "3"}, &requests)
+
+	in := `{"date":"20180916","quantity":"4"}` + "\n" + `{"date":"20180917","quantity":"1"}` + "\n"
+	client := Client{UserName: userName, Token: token}
+	_, err := client.Graph(graphID).Import(context.Background(), strings.NewReader(in), FormatJSONLines, false)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := []string{
+		http.MethodPost + " /v1/users/" + userName + "/graphs/" + graphID,
+		http.MethodPut + " /v1/users/" + userName + "/graphs/" + graphID + "/20180916",
+	}
+	actual := requests[len(requests)-2:]
+	if reflect.DeepEqual(actual, expect) == false {
+		t.Errorf("got: %v\nwant: %v", actual, expect)
+	}
+}
+
+func TestGraphImportGetFailure(t *testing.T) {
+	clientMock = &httpClientMock{
+		statusCode: http.StatusServiceUnavailable,
+		body:       []byte(`{"message":"Please retry this request.","isSuccess":false}`),
+	}
+
+	client := Client{UserName: userName, Token: token}
+	diff, err := diffPixels(context.Background(), client.Pixel(graphID), []PixelValue{{Date: "20180915", Quantity: "5"}})
+	if err == nil {
+		t.Errorf("got: %v\nwant: the failure of the pixel get", diff)
+	}
+}
+
+func TestGraphImportInvalidQuantity(t *testing.T) {
+	var requests []string
+	clientMock = newExportMock(map[string]string{}, &requests)
+
+	in := "20180915,1.5\n"
+	client := Client{UserName: userName, Token: token}
+	_, err := client.Graph(graphID).Import(context.Background(), strings.NewReader(in), FormatCSV, false)
+	if err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+	for _, r := range requests {
+		if strings.HasPrefix(r, http.MethodGet) == false {
+			t.Errorf("invalid pixels sent: %s", r)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Package gitimport mirrors the commit activity of a local git repository into a Pixela graph.
+//
+// The history is read with the git command, so git must be installed. No network is used.
+package gitimport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/pkg/errors"
+)
+
+const dateFormat = "20060102"
+
+// Importer imports the commit counts of a repository.
+type Importer struct {
+	// Repository is the path of the repository.
+	Repository string
+	// Authors are the emails of the authors whose commits are counted. If empty, all commits are counted.
+	Authors []string
+	// Since is the first date imported in yyyyMMdd format. If empty, the whole history is imported.
+	Since string
+	// StatePath is the file that remembers the last synced date.
+	// If it is set, Since is read from it and it is updated after each successful Sync or Mirror.
+	StatePath string
+}
+
+// Activity is the number of commits per date per author email.
+type Activity map[string]map[string]int
+
+// Total returns the number of commits of the date.
+func (a Activity) Total(date string) int {
+	total := 0
+	for _, n := range a[date] {
+		total += n
+	}
+	return total
+}
+
+// Dates returns the dates that have commits in order.
+func (a Activity) Dates() []string {
+	var dates []string
+	for date := range a {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+// Count counts the commits of HEAD per day in location by the author date.
+func (i *Importer) Count(ctx context.Context, location *time.Location) (Activity, error) {
+	since, err := i.since()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", i.Repository, "log", "--format=%at%x09%ae", "HEAD")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run git log: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	authors := map[string]bool{}
+	for _, a := range i.Authors {
+		authors[strings.ToLower(a)] = true
+	}
+
+	activity := Activity{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse author date")
+		}
+		author := strings.ToLower(fields[1])
+		if len(authors) > 0 && authors[author] == false {
+			continue
+		}
+
+		date := time.Unix(seconds, 0).In(location).Format(dateFormat)
+		if date < since {
+			continue
+		}
+		if activity[date] == nil {
+			activity[date] = map[string]int{}
+		}
+		activity[date][author]++
+	}
+	return activity, errors.Wrap(scanner.Err(), "failed to read git log")
+}
+
+// Pixels returns the number of commits per day in location as the pixels. It makes Importer a pixela.Source.
+func (i *Importer) Pixels(ctx context.Context, location *time.Location) ([]pixela.PixelValue, error) {
+	activity, err := i.Count(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	var pixels []pixela.PixelValue
+	for _, date := range activity.Dates() {
+		pixels = append(pixels, pixela.PixelValue{Date: date, Quantity: strconv.Itoa(activity.Total(date))})
+	}
+	return pixels, nil
+}
+
+// SyncResult is the result of Sync.
+type SyncResult struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+	// LastDate is the last date that has commits, or the date synced before if there are none.
+	LastDate string
+}
+
+// Sync counts the commits in the timezone of the graph and records them as the pixels of the graph.
+// The pixels of the dates that have commits are created, or updated if their quantities differ.
+func (i *Importer) Sync(ctx context.Context, client *pixela.Client, graphID string) (*SyncResult, error) {
+	return i.sync(ctx, &clientAPI{client: client}, graphID)
+}
+
+// graphAPI is the part of the Pixela API used by Importer.
+type graphAPI interface {
+	definition(graphID string) (*pixela.GraphDefinition, error)
+	get(graphID, date string) (*pixela.Quantity, error)
+	create(graphID, date, quantity, optionalData string) (*pixela.Result, error)
+	update(graphID, date, quantity, optionalData string) (*pixela.Result, error)
+}
+
+type clientAPI struct {
+	client *pixela.Client
+}
+
+func (c *clientAPI) definition(graphID string) (*pixela.GraphDefinition, error) {
+	definitions, err := c.client.Graph(graphID).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if definitions.IsSuccess == false {
+		return nil, errors.Errorf("failed to call API: %s", definitions.Message)
+	}
+	for _, d := range definitions.Graphs {
+		if d.ID == graphID {
+			return &d, nil
+		}
+	}
+	return nil, errors.Errorf("graph not found: %s", graphID)
+}
+
+func (c *clientAPI) get(graphID, date string) (*pixela.Quantity, error) {
+	return c.client.Pixel(graphID).Get(date)
+}
+
+func (c *clientAPI) create(graphID, date, quantity, optionalData string) (*pixela.Result, error) {
+	return c.client.Pixel(graphID).Create(date, quantity, optionalData)
+}
+
+func (c *clientAPI) update(graphID, date, quantity, optionalData string) (*pixela.Result, error) {
+	return c.client.Pixel(graphID).Update(date, quantity, optionalData)
+}
+
+func (i *Importer) sync(ctx context.Context, api graphAPI, graphID string) (*SyncResult, error) {
+	definition, err := api.definition(graphID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get graph definition")
+	}
+	location := time.UTC
+	if definition.TimeZone != "" {
+		if location, err = time.LoadLocation(definition.TimeZone); err != nil {
+			return nil, errors.Wrapf(err, "failed to load timezone")
+		}
+	}
+
+	activity, err := i.Count(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	since, err := i.since()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{LastDate: since}
+	for _, date := range activity.Dates() {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		quantity := strconv.Itoa(activity.Total(date))
+		current, err := api.get(graphID, date)
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to get pixel %s", date)
+		}
+		switch {
+		case current.IsSuccess == false && current.StatusCode != http.StatusNotFound:
+			// Only a missing pixel is created; the other failures such as "503 Please retry" must not be.
+			return result, errors.Errorf("failed to get pixel %s: %s", date, current.Message)
+		case current.IsSuccess == false:
+			err = checkResult(api.create(graphID, date, quantity, ""))
+			result.Created = append(result.Created, date)
+		case current.Quantity != quantity:
+			err = checkResult(api.update(graphID, date, quantity, current.OptionalData))
+			result.Updated = append(result.Updated, date)
+		default:
+			result.Unchanged = append(result.Unchanged, date)
+		}
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to sync pixel %s", date)
+		}
+		result.LastDate = date
+	}
+
+	return result, i.saveState(result.LastDate)
+}
+
+func checkResult(result *pixela.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if result.IsSuccess == false {
+		return errors.Errorf("failed to call API: %s", result.Message)
+	}
+	return nil
+}
+
+// Mirror is Sync that makes the pixels of the graph the same as the commit counts with Graph.Sync.
+// Unlike Sync, the pixels after the first imported date that have no commits are deleted.
+// If dryRun is true, it only returns the changes without applying them.
+func (i *Importer) Mirror(ctx context.Context, client *pixela.Client, graphID string, dryRun bool) (*pixela.SyncDiff, error) {
+	since, err := i.since()
+	if err != nil {
+		return nil, err
+	}
+
+	var lastDate string
+	source := pixela.SourceFunc(func(ctx context.Context, location *time.Location) ([]pixela.PixelValue, error) {
+		pixels, err := i.Pixels(ctx, location)
+		if len(pixels) > 0 {
+			lastDate = pixels[len(pixels)-1].Date
+		}
+		return pixels, err
+	})
+	diff, err := client.Graph(graphID).Sync(ctx, source, &pixela.SyncOptions{Aggregation: pixela.AggregateSum, From: since, DryRun: dryRun})
+	if err != nil || dryRun {
+		return diff, err
+	}
+
+	return diff, i.saveState(lastDate)
+}
+
+type state struct {
+	LastSyncedDate string `json:"lastSyncedDate"`
+}
+
+// since returns the first date to import.
+// The last synced date is imported again because commits may have been added on the day after the sync.
+func (i *Importer) since() (string, error) {
+	if i.StatePath == "" {
+		return i.Since, nil
+	}
+
+	b, err := ioutil.ReadFile(i.StatePath)
+	if os.IsNotExist(err) {
+		return i.Since, nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read state")
+	}
+	var s state
+	if err := json.Unmarshal(b, &s); err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal state")
+	}
+	if s.LastSyncedDate > i.Since {
+		return s.LastSyncedDate, nil
+	}
+	return i.Since, nil
+}
+
+func (i *Importer) saveState(lastDate string) error {
+	if i.StatePath == "" || lastDate == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(&state{LastSyncedDate: lastDate})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal state")
+	}
+	return errors.Wrap(writeFileAtomic(i.StatePath, b, 0600), "failed to write state")
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to path,
+// so that a crash never leaves a truncated state behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to write temporary file")
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to sync temporary file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to close temporary file")
+	}
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return errors.Wrap(err, "failed to change mode of temporary file")
+	}
+	return errors.Wrap(os.Rename(f.Name(), path), "failed to rename temporary file")
+}
@@ -0,0 +1,203 @@
+package gitimport
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+)
+
+// newTestRepository returns a repository with commits at the author dates by the authors.
+func newTestRepository(t *testing.T, commits [][2]string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "gitimport")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(env []string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git(nil, "init", "-q")
+	for _, c := range commits {
+		git([]string{
+			"GIT_AUTHOR_NAME=author", "GIT_AUTHOR_EMAIL=" + c[1], "GIT_AUTHOR_DATE=" + c[0],
+			"GIT_COMMITTER_NAME=author", "GIT_COMMITTER_EMAIL=" + c[1], "GIT_COMMITTER_DATE=" + c[0],
+		}, "commit", "-q", "--allow-empty", "-m", "commit")
+	}
+	return dir
+}
+
+var testCommits = [][2]string{
+	{"2018-09-14T10:00:00Z", "alice@example.com"},
+	{"2018-09-14T16:00:00Z", "alice@example.com"},
+	{"2018-09-14T17:00:00Z", "Bob@example.com"},
+	{"2018-09-15T10:00:00Z", "bob@example.com"},
+}
+
+type apiMock struct {
+	timezone string
+	pixels   map[string]string
+	calls    []string
+	// getFailure is the failure of get if it is set.
+	getFailure *pixela.Result
+}
+
+func (m *apiMock) definition(graphID string) (*pixela.GraphDefinition, error) {
+	return &pixela.GraphDefinition{ID: graphID, TimeZone: m.timezone}, nil
+}
+
+func (m *apiMock) get(graphID, date string) (*pixela.Quantity, error) {
+	if m.getFailure != nil {
+		return &pixela.Quantity{Result: *m.getFailure}, nil
+	}
+	q, ok := m.pixels[date]
+	if ok == false {
+		return &pixela.Quantity{Result: pixela.Result{Message: "Specified pixel not found.", StatusCode: http.StatusNotFound}}, nil
+	}
+	return &pixela.Quantity{Quantity: q, Result: pixela.Result{IsSuccess: true}}, nil
+}
+
+func (m *apiMock) create(graphID, date, quantity, optionalData string) (*pixela.Result, error) {
+	m.calls = append(m.calls, "create "+date+" "+quantity)
+	m.pixels[date] = quantity
+	return &pixela.Result{Message: "Success.", IsSuccess: true}, nil
+}
+
+func (m *apiMock) update(graphID, date, quantity, optionalData string) (*pixela.Result, error) {
+	m.calls = append(m.calls, "update "+date+" "+quantity)
+	m.pixels[date] = quantity
+	return &pixela.Result{Message: "Success.", IsSuccess: true}, nil
+}
+
+func TestImporterSync(t *testing.T) {
+	dir := newTestRepository(t, testCommits)
+	defer os.RemoveAll(dir)
+
+	api := &apiMock{timezone: "Asia/Tokyo", pixels: map[string]string{"20180915": "1"}}
+	importer := &Importer{Repository: dir, StatePath: filepath.Join(dir, "state.json")}
+	result, err := importer.sync(context.Background(), api, "graph-id")
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	// In Asia/Tokyo, 16:00 and 17:00 UTC are on the next day.
+	expect := &SyncResult{Created: []string{"20180914"}, Updated: []string{"20180915"}, LastDate: "20180915"}
+	if reflect.DeepEqual(result, expect) == false {
+		t.Errorf("got: %v\nwant: %v", result, expect)
+	}
+	if api.pixels["20180914"] != "1" || api.pixels["20180915"] != "3" {
+		t.Errorf("got: %v", api.pixels)
+	}
+
+	// The incremental run starts at the last synced date.
+	api.calls = nil
+	result, err = importer.sync(context.Background(), api, "graph-id")
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect = &SyncResult{Unchanged: []string{"20180915"}, LastDate: "20180915"}
+	if reflect.DeepEqual(result, expect) == false || len(api.calls) != 0 {
+		t.Errorf("got: %v, %v\nwant: %v", result, api.calls, expect)
+	}
+}
+
+func TestImporterSyncGetFailure(t *testing.T) {
+	dir := newTestRepository(t, testCommits)
+	defer os.RemoveAll(dir)
+
+	api := &apiMock{
+		pixels:     map[string]string{},
+		getFailure: &pixela.Result{Message: "Please retry this request.", StatusCode: http.StatusServiceUnavailable},
+	}
+	importer := &Importer{Repository: dir}
+	if _, err := importer.sync(context.Background(), api, "graph-id"); err == nil {
+		t.Errorf("got: nil\nwant: the failure of the pixel get")
+	}
+	if len(api.calls) != 0 {
+		t.Errorf("got: %v\nwant: no pixels created", api.calls)
+	}
+}
+
+func TestImporterPixels(t *testing.T) {
+	dir := newTestRepository(t, testCommits)
+	defer os.RemoveAll(dir)
+
+	location, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	importer := &Importer{Repository: dir}
+	pixels, err := importer.Pixels(context.Background(), location)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	// In Asia/Tokyo, 16:00 and 17:00 UTC are on the next day.
+	expect := []pixela.PixelValue{{Date: "20180914", Quantity: "1"}, {Date: "20180915", Quantity: "3"}}
+	if reflect.DeepEqual(pixels, expect) == false {
+		t.Errorf("got: %v\nwant: %v", pixels, expect)
+	}
+}
+
+func TestImporterState(t *testing.T) {
+	dir := newTestRepository(t, testCommits)
+	defer os.RemoveAll(dir)
+
+	importer := &Importer{Repository: dir, Since: "20180901", StatePath: filepath.Join(dir, "state.json")}
+	if err := importer.saveState("20180914"); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	// The incremental run starts at the last synced date.
+	pixels, err := importer.Pixels(context.Background(), time.UTC)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect := []pixela.PixelValue{{Date: "20180914", Quantity: "3"}, {Date: "20180915", Quantity: "1"}}
+	if reflect.DeepEqual(pixels, expect) == false {
+		t.Errorf("got: %v\nwant: %v", pixels, expect)
+	}
+}
+
+func TestImporterCountAuthors(t *testing.T) {
+	dir := newTestRepository(t, testCommits)
+	defer os.RemoveAll(dir)
+
+	importer := &Importer{Repository: dir, Authors: []string{"bob@example.com"}, Since: "20180915"}
+	activity, err := importer.Count(context.Background(), time.UTC)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := Activity{"20180915": {"bob@example.com": 1}}
+	if reflect.DeepEqual(activity, expect) == false {
+		t.Errorf("got: %v\nwant: %v", activity, expect)
+	}
+}
+
+func TestImporterCountFail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitimport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	importer := &Importer{Repository: dir}
+	if _, err := importer.Count(context.Background(), time.UTC); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
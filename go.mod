@@ -2,4 +2,7 @@ module github.com/ebc-2in2crc/pixela-client-go
 
 go 1.12
 
-require github.com/pkg/errors v0.8.1
+require (
+	github.com/pkg/errors v0.8.1
+	golang.org/x/crypto v0.9.0
+)
@@ -9,20 +9,33 @@ import (
 )
 
 // A Graph manages communication with the Pixela graph API.
+// If it is returned by Client.Graph, it uses the current credentials of the Client
+// instead of UserName and Token, so that a token rotated by Client.UpdateUser reaches it.
 type Graph struct {
 	UserName string
 	Token    string
 	GraphID  string
+
+	client *Client
+	state  *credentialState
 }
 
 // Create creates a new pixelation graph definition.
 func (g *Graph) Create(name, unit, quantityType, color, timezone, selfSufficient string, isSecret, publishOptionalData bool) (*Result, error) {
+	if err := validate(ValidateUserName(g.userName()), ValidateGraphID(g.GraphID)); err != nil {
+		return &Result{}, err
+	}
+
 	param, err := g.createCreateRequestParameter(name, unit, quantityType, color, timezone, selfSufficient, isSecret, publishOptionalData)
 	if err != nil {
 		return &Result{}, errors.Wrapf(err, "failed to create graph create parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := g.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		g.client.invalidateGraph(g.userName(), g.GraphID, true)
+	}
+	return result, err
 }
 
 func (g *Graph) createCreateRequestParameter(name, unit, quantityType, color, timezone, selfSufficient string, isSecret, publishOptionalData bool) (*requestParameter, error) {
@@ -43,10 +56,11 @@ func (g *Graph) createCreateRequestParameter(name, unit, quantityType, color, ti
 	}
 
 	return &requestParameter{
-		Method: http.MethodPost,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs", g.UserName),
-		Header: map[string]string{userToken: g.Token},
-		Body:   b,
+		Operation: "Graph.Create",
+		Method:    http.MethodPost,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs", g.userName()),
+		Header:    map[string]string{userToken: g.token()},
+		Body:      b,
 	}, nil
 }
 
@@ -97,26 +111,24 @@ func (g *Graph) GetAll() (*GraphDefinitions, error) {
 		return &GraphDefinitions{}, errors.Wrapf(err, "failed to create get all graph parameter")
 	}
 
-	b, err := doRequest(param)
-	if err != nil {
-		return &GraphDefinitions{}, errors.Wrapf(err, "failed to do request")
-	}
-
 	var definitions GraphDefinitions
-	if err := json.Unmarshal(b, &definitions); err != nil {
-		return &GraphDefinitions{}, errors.Wrapf(err, "failed to unmarshal json")
+	statusCode, err := g.client.cachedDecode(g.userName(), graphDefinitionsTTL, param, &definitions)
+	if err != nil {
+		return &GraphDefinitions{}, err
 	}
 
+	definitions.StatusCode = statusCode
 	definitions.IsSuccess = definitions.Message == ""
 	return &definitions, nil
 }
 
 func (g *Graph) createGetAllRequestParameter() (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodGet,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs", g.UserName),
-		Header: map[string]string{userToken: g.Token},
-		Body:   []byte{},
+		Operation: "Graph.GetAll",
+		Method:    http.MethodGet,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs", g.userName()),
+		Header:    map[string]string{userToken: g.token()},
+		Body:      []byte{},
 	}, nil
 }
 
@@ -141,13 +153,20 @@ type GraphDefinition struct {
 }
 
 // GetSVG get a graph expressed in SVG format diagram that based on the registered information.
+// The Graph returned by the Client requests it conditionally as GetSVGConditional does.
 func (g *Graph) GetSVG(date, mode string) (string, error) {
 	param, err := g.createGetSVGRequestParameter(date, mode)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to create get svg parameter")
 	}
 
-	b, err := mustDoRequest(param)
+	b, err := g.client.cachedRequest(g.userName(), svgTTL, param, func(param *requestParameter) ([]byte, error) {
+		svg, err := g.client.getSVG(g.userName(), param)
+		if err != nil {
+			return []byte{}, err
+		}
+		return []byte(svg.Body), nil
+	})
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to do request")
 	}
@@ -157,10 +176,11 @@ func (g *Graph) GetSVG(date, mode string) (string, error) {
 
 func (g *Graph) createGetSVGRequestParameter(date, mode string) (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodGet,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s?date=%s&mode=%s", g.UserName, g.GraphID, date, mode),
-		Header: map[string]string{userToken: token},
-		Body:   []byte{},
+		Operation: "Graph.GetSVG",
+		Method:    http.MethodGet,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s?date=%s&mode=%s", g.userName(), g.GraphID, date, mode),
+		Header:    map[string]string{userToken: g.token()},
+		Body:      []byte{},
 	}, nil
 }
 
@@ -182,15 +202,15 @@ const (
 // URL displays the details of the graph in html format.
 func (g *Graph) URL(mode string) string {
 	if len(mode) == 0 {
-		return fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s.html", g.UserName, g.GraphID)
+		return fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s.html", g.userName(), g.GraphID)
 	}
 
-	return fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s.html?mode=%s", g.UserName, g.GraphID, mode)
+	return fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s.html?mode=%s", g.userName(), g.GraphID, mode)
 }
 
 // GraphsURL displays graph list by detail in html format.
 func (g *Graph) GraphsURL() string {
-	return fmt.Sprintf(APIBaseURL+"/users/%s/graphs.html", g.UserName)
+	return fmt.Sprintf(APIBaseURL+"/users/%s/graphs.html", g.userName())
 }
 
 // Stats is various statistics based on the registered information.
@@ -211,26 +231,24 @@ func (g *Graph) Stats() (*Stats, error) {
 		return nil, errors.Wrapf(err, "failed to create graph stats request parameter")
 	}
 
-	b, err := doRequest(param)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to do request")
-	}
-
 	var stats Stats
-	if err := json.Unmarshal(b, &stats); err != nil {
-		return nil, errors.Wrapf(err, "failed to unmarshal json")
+	statusCode, err := g.client.cachedDecode(g.userName(), statsTTL, param, &stats)
+	if err != nil {
+		return nil, err
 	}
 
+	stats.StatusCode = statusCode
 	stats.IsSuccess = stats.Message == ""
 	return &stats, nil
 }
 
 func (g *Graph) createStatsRequestParameter() (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodGet,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/stats", g.UserName, g.GraphID),
-		Header: map[string]string{},
-		Body:   []byte{},
+		Operation: "Graph.Stats",
+		Method:    http.MethodGet,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/stats", g.userName(), g.GraphID),
+		Header:    map[string]string{},
+		Body:      []byte{},
 	}, nil
 }
 
@@ -242,7 +260,11 @@ func (g *Graph) Update(name, unit, color, timezone string, purgeCacheUrls []stri
 		return &Result{}, errors.Wrapf(err, "failed to create graph update parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := g.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		g.client.invalidateGraph(g.userName(), g.GraphID, true)
+	}
+	return result, err
 }
 
 func (g *Graph) createUpdateRequestParameter(name, unit, color, timezone string, purgeCacheUrls []string, selfSufficient string, isSecret, publishOptionalData bool) (*requestParameter, error) {
@@ -262,10 +284,11 @@ func (g *Graph) createUpdateRequestParameter(name, unit, color, timezone string,
 	}
 
 	return &requestParameter{
-		Method: http.MethodPut,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s", g.UserName, g.GraphID),
-		Header: map[string]string{userToken: g.Token},
-		Body:   b,
+		Operation: "Graph.Update",
+		Method:    http.MethodPut,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s", g.userName(), g.GraphID),
+		Header:    map[string]string{userToken: g.token()},
+		Body:      b,
 	}, nil
 }
 
@@ -287,15 +310,20 @@ func (g *Graph) Delete() (*Result, error) {
 		return &Result{}, errors.Wrapf(err, "failed to create graph delete parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := g.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		g.client.invalidateGraph(g.userName(), g.GraphID, true)
+	}
+	return result, err
 }
 
 func (g *Graph) createDeleteRequestParameter() (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodDelete,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s", g.UserName, g.GraphID),
-		Header: map[string]string{userToken: g.Token},
-		Body:   []byte{},
+		Operation: "Graph.Delete",
+		Method:    http.MethodDelete,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s", g.userName(), g.GraphID),
+		Header:    map[string]string{userToken: g.token()},
+		Body:      []byte{},
 	}, nil
 }
 
@@ -320,16 +348,13 @@ func (g *Graph) GetPixelDates(from, to string) (*Pixels, error) {
 		return &Pixels{}, errors.Wrapf(err, "failed to create get pixel dates parameter")
 	}
 
-	b, err := doRequest(param)
-	if err != nil {
-		return &Pixels{}, errors.Wrapf(err, "failed to do request")
-	}
-
 	var pixels Pixels
-	if err := json.Unmarshal(b, &pixels); err != nil {
-		return &Pixels{}, errors.Wrapf(err, "failed to unmarshal json")
+	statusCode, err := g.client.doRequestAndDecode(param, &pixels)
+	if err != nil {
+		return &Pixels{}, err
 	}
 
+	pixels.StatusCode = statusCode
 	pixels.IsSuccess = pixels.Message == ""
 	return &pixels, nil
 }
@@ -342,9 +367,18 @@ type Pixels struct {
 
 func (g *Graph) createGetPixelDatesRequestParameter(from, to string) (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodGet,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/pixels?from=%s&to=%s", g.UserName, g.GraphID, from, to),
-		Header: map[string]string{userToken: g.Token},
-		Body:   []byte{},
+		Operation: "Graph.GetPixelDates",
+		Method:    http.MethodGet,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/pixels?from=%s&to=%s", g.userName(), g.GraphID, from, to),
+		Header:    map[string]string{userToken: g.token()},
+		Body:      []byte{},
 	}, nil
 }
+
+func (g *Graph) userName() string {
+	return g.state.userNameOr(g.UserName)
+}
+
+func (g *Graph) token() string {
+	return g.state.tokenOr(g.Token)
+}
@@ -10,7 +10,7 @@ import (
 
 func TestCreateGraphCreateRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Graph(graphID).createCreateRequestParameter(
+	param, err := client.graph(graphID).createCreateRequestParameter(
 		"name", "times", TypeInt, ColorShibafu, "UTC", SelfSufficientIncrement, true, true)
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
@@ -68,7 +68,7 @@ func TestGraphCreateError(t *testing.T) {
 
 func TestCreateGraphGetAllRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Graph(graphID).createGetAllRequestParameter()
+	param, err := client.graph(graphID).createGetAllRequestParameter()
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -117,7 +117,7 @@ func TestGraphGetAll(t *testing.T) {
 				PublishOptionalData: true,
 			},
 		},
-		Result: Result{IsSuccess: true},
+		Result: Result{IsSuccess: true, StatusCode: http.StatusOK},
 	}
 	if reflect.DeepEqual(definitions, expect) == false {
 		t.Errorf("got: %v\nwant: %v", definitions, expect)
@@ -147,7 +147,7 @@ func TestGraphGetAllError(t *testing.T) {
 
 func TestCreateGraphGetSVGRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Graph(graphID).createGetSVGRequestParameter("20180101", ModeShort)
+	param, err := client.graph(graphID).createGetSVGRequestParameter("20180101", ModeShort)
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -234,7 +234,7 @@ func TestGraphsUrl(t *testing.T) {
 
 func TestCreateStatsRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Graph(graphID).createStatsRequestParameter()
+	param, err := client.graph(graphID).createStatsRequestParameter()
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -271,7 +271,7 @@ func TestGraphStats(t *testing.T) {
 		TotalQuantity:    4,
 		AvgQuantity:      5.0,
 		TodaysQuantity:   6,
-		Result:           Result{IsSuccess: true},
+		Result:           Result{IsSuccess: true, StatusCode: http.StatusOK},
 	}
 	if *stats != *expect {
 		t.Errorf("got: %v\nwant: %v", stats, expect)
@@ -301,7 +301,7 @@ func TestGraphStatsError(t *testing.T) {
 
 func TestCreateGraphUpdateRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Graph(graphID).createUpdateRequestParameter(
+	param, err := client.graph(graphID).createUpdateRequestParameter(
 		"name", "times", ColorShibafu, "UTC", []string{"https://camo.githubusercontent.com/xxx/xxxx"}, SelfSufficientIncrement, true, true)
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
@@ -359,7 +359,7 @@ func TestGraphUpdateError(t *testing.T) {
 
 func TestCreateGraphDeleteRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Graph(graphID).createDeleteRequestParameter()
+	param, err := client.graph(graphID).createDeleteRequestParameter()
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -411,7 +411,7 @@ func TestGraphDeleteError(t *testing.T) {
 
 func TestCreateGraphGetPixelDatesRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Graph(graphID).createGetPixelDatesRequestParameter("20180101", "20181231")
+	param, err := client.graph(graphID).createGetPixelDatesRequestParameter("20180101", "20181231")
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -447,7 +447,7 @@ func TestGraphGetPixelDates(t *testing.T) {
 
 	expect := &Pixels{
 		Pixels: []string{"20180101", "20180331"},
-		Result: Result{IsSuccess: true},
+		Result: Result{IsSuccess: true, StatusCode: http.StatusOK},
 	}
 	if reflect.DeepEqual(pixels, expect) == false {
 		t.Errorf("got: %v\nwant: %v", pixels, expect)
@@ -0,0 +1,102 @@
+package pixela
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// LogEntry is the record of a request sent to the Pixela API.
+// The URL, the message, the error and the bodies have the secrets masked by Redact.
+type LogEntry struct {
+	// Operation is the name of the method that sent the request, such as "Pixel.Increment".
+	Operation string
+	Method    string
+	URL       string
+	// StatusCode is 0 if no response was received.
+	StatusCode int
+	Latency    time.Duration
+	// Message is the message of the Pixela response, if any.
+	Message string
+	// Attempt is the number of times the request has been sent, starting at 1.
+	Attempt int
+	Err     error
+	// DryRun is true if the request was recorded in the dry-run mode and not sent.
+	// Its StatusCode and Attempt are 0.
+	DryRun bool
+
+	// RequestBody and ResponseBody are set only in the verbose mode.
+	RequestBody  string
+	ResponseBody string
+}
+
+// Logger receives a LogEntry for every request a Client sends.
+// It may be called concurrently.
+type Logger interface {
+	Log(entry *LogEntry)
+}
+
+// LoggerFunc is a function that implements Logger.
+type LoggerFunc func(entry *LogEntry)
+
+// Log calls f(entry).
+func (f LoggerFunc) Log(entry *LogEntry) {
+	f(entry)
+}
+
+type stdLogger struct {
+	logger *log.Logger
+}
+
+// NewStdLogger returns a Logger that writes each LogEntry to logger as a line of key=value pairs.
+// If logger is nil, the lines are written to the standard error.
+func NewStdLogger(logger *log.Logger) Logger {
+	if logger == nil {
+		logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &stdLogger{logger: logger}
+}
+
+func (l *stdLogger) Log(entry *LogEntry) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "operation=%s method=%s url=%q status=%d latency=%s attempt=%d",
+		entry.Operation, entry.Method, entry.URL, entry.StatusCode, entry.Latency, entry.Attempt)
+	if entry.DryRun {
+		b.WriteString(" dry-run=true")
+	}
+	if entry.Message != "" {
+		fmt.Fprintf(&b, " message=%q", entry.Message)
+	}
+	if entry.Err != nil {
+		fmt.Fprintf(&b, " error=%q", entry.Err.Error())
+	}
+	if entry.RequestBody != "" {
+		fmt.Fprintf(&b, " request=%q", entry.RequestBody)
+	}
+	if entry.ResponseBody != "" {
+		fmt.Fprintf(&b, " response=%q", entry.ResponseBody)
+	}
+	l.logger.Print(b.String())
+}
+
+// EnableLogging logs every request of the Client and the Graph, Pixel and Webhook returned by it to logger.
+// If verbose is true, the request and response bodies are logged too.
+// It must be called before the Client is used.
+func (c *Client) EnableLogging(logger Logger, verbose bool) {
+	c.logger = logger
+	c.verbose = verbose
+}
+
+// pixelaMessage returns the message of the Pixela response body, or "" if it has no message.
+func pixelaMessage(body []byte) string {
+	var result struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &result) != nil {
+		return ""
+	}
+	return result.Message
+}
@@ -0,0 +1,158 @@
+package pixela
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type logRecorder struct {
+	mu      sync.Mutex
+	entries []*LogEntry
+}
+
+func (r *logRecorder) Log(entry *LogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+func TestClientLogging(t *testing.T) {
+	clientMock = newOKMock()
+
+	recorder := &logRecorder{}
+	client := NewClient(userName, token)
+	client.EnableLogging(recorder, false)
+	client.Pixel(graphID).Increment()
+
+	if len(recorder.entries) != 1 {
+		t.Fatalf("got: %d entries\nwant: 1", len(recorder.entries))
+	}
+	got := recorder.entries[0]
+	expect := &LogEntry{
+		Operation:  "Pixel.Increment",
+		Method:     http.MethodPut,
+		URL:        APIBaseURL + "/users/user/graphs/graph-id/increment",
+		StatusCode: http.StatusOK,
+		Latency:    got.Latency,
+		Message:    "Success.",
+		Attempt:    1,
+	}
+	if *got != *expect {
+		t.Errorf("got: %+v\nwant: %+v", got, expect)
+	}
+}
+
+func TestClientLoggingError(t *testing.T) {
+	clientMock = newPageNotFoundMock()
+
+	recorder := &logRecorder{}
+	client := NewClient(userName, token)
+	client.EnableLogging(recorder, false)
+	_, err := client.Graph(graphID).Delete()
+
+	got := recorder.entries[0]
+	if got.StatusCode != http.StatusNotFound || got.Err != err || got.Message != "" {
+		t.Errorf("got: %+v\nwant: 404 with the error %v", got, err)
+	}
+}
+
+func TestClientLoggingVerbose(t *testing.T) {
+	clientMock = &httpClientMock{
+		statusCode: http.StatusOK,
+		body:       []byte(`{"message":"Success.","isSuccess":true,"token":"` + token + `"}`),
+	}
+
+	recorder := &logRecorder{}
+	client := NewClient(userName, token)
+	client.EnableLogging(recorder, true)
+	client.UpdateUser(newToken, "")
+	client.Webhook().Invoke(webhookHash)
+
+	update, invoke := recorder.entries[0], recorder.entries[1]
+	if update.Operation != "Client.UpdateUser" || strings.Contains(update.RequestBody, `"newToken":"[REDACTED]"`) == false {
+		t.Errorf("got: %+v\nwant: the request body with newToken redacted", update)
+	}
+	if strings.Contains(update.ResponseBody, `"isSuccess":true`) == false {
+		t.Errorf("got: %s\nwant: the response body", update.ResponseBody)
+	}
+	if invoke.URL != APIBaseURL+"/users/user/webhooks/"+Redacted {
+		t.Errorf("got: %s\nwant: the URL with the webhook hash redacted", invoke.URL)
+	}
+
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0))
+	for _, entry := range recorder.entries {
+		logger.Log(entry)
+	}
+	testRedacted(t, buf.String())
+}
+
+func TestClientLoggingCoversAllOperations(t *testing.T) {
+	clientMock = newOKMock()
+
+	recorder := &logRecorder{}
+	client := NewClient(userName, token)
+	client.EnableLogging(recorder, false)
+	graph, pixel, webhook := client.Graph(graphID), client.Pixel(graphID), client.Webhook()
+	calls := []func(){
+		func() { client.CreateUser(true, true, "") },
+		func() { client.UpdateUser(newToken, "") },
+		func() { client.DeleteUser() },
+		func() { graph.Create("name", "unit", TypeInt, ColorShibafu, "", "", false, false) },
+		func() { graph.GetAll() },
+		func() { graph.GetSVG("", "") },
+		func() { graph.Stats() },
+		func() { graph.Update("name", "unit", ColorShibafu, "", nil, "", false, false) },
+		func() { graph.Delete() },
+		func() { graph.GetPixelDates("", "") },
+		func() { pixel.Create("20180915", "5", "") },
+		func() { pixel.Increment() },
+		func() { pixel.Decrement() },
+		func() { pixel.Add("1") },
+		func() { pixel.Get("20180915") },
+		func() { pixel.Update("20180915", "5", "") },
+		func() { pixel.Delete("20180915") },
+		func() { webhook.Create(graphID, SelfSufficientIncrement) },
+		func() { webhook.GetAll() },
+		func() { webhook.Delete(webhookHash) },
+		func() { webhook.Invoke(webhookHash) },
+	}
+	for _, call := range calls {
+		call()
+	}
+
+	operations := map[string]bool{}
+	for _, entry := range recorder.entries {
+		if entry.Operation == "" {
+			t.Errorf("got: %+v\nwant: the operation", entry)
+		}
+		operations[entry.Operation] = true
+	}
+	if len(recorder.entries) != len(calls) || len(operations) != len(calls) {
+		t.Errorf("got: %v\nwant: %d operations", operations, len(calls))
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0))
+	logger.Log(&LogEntry{
+		Operation:  "Pixel.Get",
+		Method:     http.MethodGet,
+		URL:        "https://pixe.la/v1/users/user/graphs/graph-id/20180915",
+		StatusCode: http.StatusNotFound,
+		Latency:    12 * time.Millisecond,
+		Message:    "Specified pixel not found.",
+		Attempt:    1,
+	})
+
+	expect := `operation=Pixel.Get method=GET url="https://pixe.la/v1/users/user/graphs/graph-id/20180915" status=404 latency=12ms attempt=1 message="Specified pixel not found."` + "\n"
+	if buf.String() != expect {
+		t.Errorf("got: %s\nwant: %s", buf.String(), expect)
+	}
+}
@@ -0,0 +1,278 @@
+// Package metrics exposes the statistics of Pixela graphs as Prometheus metrics.
+//
+// The metrics are written in the Prometheus text exposition format, and are refreshed at most once per
+// refresh interval however often they are scraped.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/pkg/errors"
+)
+
+const (
+	contentType            = "text/plain; version=0.0.4; charset=utf-8"
+	dateFormat             = "20060102"
+	defaultRefreshInterval = 5 * time.Minute
+)
+
+// Exporter is an http.Handler that serves the metrics of all graphs of the user.
+type Exporter struct {
+	// RefreshInterval is how long the metrics are served without calling the API. If 0, 5 minutes is used.
+	RefreshInterval time.Duration
+
+	userName string
+	api      graphAPI
+	now      func() time.Time
+
+	mu        sync.Mutex
+	body      []byte
+	graphs    []*graphMetrics
+	refreshed time.Time
+}
+
+// graphAPI is the part of the Pixela API used by Exporter.
+type graphAPI interface {
+	graphs() ([]pixela.GraphDefinition, error)
+	stats(graphID string) (*pixela.Stats, error)
+	pixelDates(graphID, from, to string) ([]string, error)
+	quantity(graphID, date string) (string, error)
+}
+
+type clientAPI struct {
+	client *pixela.Client
+}
+
+func (c *clientAPI) graphs() ([]pixela.GraphDefinition, error) {
+	definitions, err := c.client.Graph("").GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if definitions.IsSuccess == false {
+		return nil, errors.Errorf("failed to call API: %s", definitions.Message)
+	}
+	return definitions.Graphs, nil
+}
+
+func (c *clientAPI) stats(graphID string) (*pixela.Stats, error) {
+	stats, err := c.client.Graph(graphID).Stats()
+	if err != nil {
+		return nil, err
+	}
+	if stats.IsSuccess == false {
+		return nil, errors.Errorf("failed to call API: %s", stats.Message)
+	}
+	return stats, nil
+}
+
+func (c *clientAPI) pixelDates(graphID, from, to string) ([]string, error) {
+	pixels, err := c.client.Graph(graphID).GetPixelDates(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if pixels.IsSuccess == false {
+		return nil, errors.Errorf("failed to call API: %s", pixels.Message)
+	}
+	return pixels.Pixels, nil
+}
+
+func (c *clientAPI) quantity(graphID, date string) (string, error) {
+	quantity, err := c.client.Pixel(graphID).Get(date)
+	if err != nil {
+		return "", err
+	}
+	if quantity.IsSuccess == false {
+		return "", errors.Errorf("failed to call API: %s", quantity.Message)
+	}
+	return quantity.Quantity, nil
+}
+
+// NewExporter returns a new Exporter of the graphs of the user of the client.
+func NewExporter(client *pixela.Client) *Exporter {
+	return &Exporter{userName: client.UserName, api: &clientAPI{client: client}}
+}
+
+// ServeHTTP writes the metrics, refreshing them if they are older than the refresh interval.
+// If the graph definitions can not be read, the metrics of the last refresh are served with pixela_up 0.
+// If the metrics of a graph can not be read, the last metrics of the graph are served with pixela_graph_up 0
+// and the other graphs are refreshed as usual.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body := e.metrics()
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+func (e *Exporter) clock() time.Time {
+	if e.now != nil {
+		return e.now()
+	}
+	return time.Now()
+}
+
+func (e *Exporter) metrics() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	interval := e.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	now := e.clock()
+	if e.body != nil && now.Sub(e.refreshed) < interval {
+		return e.body
+	}
+
+	// A failed refresh is not retried until the interval passes either, not to hammer the API.
+	e.refreshed = now
+	graphs, err := e.collect(now)
+	var buf bytes.Buffer
+	if err != nil {
+		writeUp(&buf, 0)
+		// Keep the graph metrics of the last successful refresh.
+		graphs = e.graphs
+	} else {
+		writeUp(&buf, 1)
+		e.graphs = graphs
+	}
+	writeMetrics(&buf, e.userName, graphs)
+	e.body = buf.Bytes()
+	return e.body
+}
+
+// graphMetrics are the metrics of a graph.
+type graphMetrics struct {
+	id string
+	// up is false if the metrics could not be read in the last refresh.
+	// The other fields are then the ones of the refresh before, and stats is nil if there is none.
+	up                  bool
+	stats               *pixela.Stats
+	latestQuantity      float64
+	latestPixelUnixTime int64
+	hasLatest           bool
+}
+
+// collect reads the metrics of all graphs. It fails only if the graph definitions can not be read;
+// a graph whose metrics can not be read is kept with the metrics of the last refresh and up set to false.
+func (e *Exporter) collect(now time.Time) ([]*graphMetrics, error) {
+	definitions, err := e.api.graphs()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get graph definitions")
+	}
+
+	last := make(map[string]*graphMetrics, len(e.graphs))
+	for _, m := range e.graphs {
+		last[m.id] = m
+	}
+
+	graphs := make([]*graphMetrics, 0, len(definitions))
+	for _, d := range definitions {
+		m, err := e.collectGraph(d, now)
+		if err != nil {
+			m = &graphMetrics{id: d.ID}
+			if l, ok := last[d.ID]; ok {
+				*m = *l
+			}
+			m.up = false
+		}
+		graphs = append(graphs, m)
+	}
+	return graphs, nil
+}
+
+func (e *Exporter) collectGraph(d pixela.GraphDefinition, now time.Time) (*graphMetrics, error) {
+	stats, err := e.api.stats(d.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get stats of %s", d.ID)
+	}
+	m := &graphMetrics{id: d.ID, up: true, stats: stats}
+
+	location := time.UTC
+	if d.TimeZone != "" {
+		if l, err := time.LoadLocation(d.TimeZone); err == nil {
+			location = l
+		}
+	}
+	to := now.In(location)
+	dates, err := e.api.pixelDates(d.ID, to.AddDate(0, 0, -364).Format(dateFormat), to.Format(dateFormat))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get pixel dates of %s", d.ID)
+	}
+	if len(dates) > 0 {
+		sort.Strings(dates)
+		latest := dates[len(dates)-1]
+		q, err := e.api.quantity(d.ID, latest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get pixel %s of %s", latest, d.ID)
+		}
+		date, err := time.ParseInLocation(dateFormat, latest, location)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse date %s", latest)
+		}
+		m.latestQuantity, _ = strconv.ParseFloat(q, 64)
+		m.latestPixelUnixTime = date.Unix()
+		m.hasLatest = true
+	}
+	return m, nil
+}
+
+func writeMetrics(buf *bytes.Buffer, userName string, graphs []*graphMetrics) {
+	type gauge struct {
+		name  string
+		help  string
+		value func(m *graphMetrics) (float64, bool)
+		// stats is true if the gauge is read from the stats, which a failed graph may not have.
+		stats bool
+	}
+	gauges := []gauge{
+		{"pixela_graph_up", "Whether the last refresh of the metrics of the graph succeeded.", func(m *graphMetrics) (float64, bool) {
+			if m.up {
+				return 1, true
+			}
+			return 0, true
+		}, false},
+		{"pixela_graph_pixels", "The number of pixels of the graph.", func(m *graphMetrics) (float64, bool) { return float64(m.stats.TotalPixelsCount), true }, true},
+		{"pixela_graph_quantity_total", "The total quantity of the graph.", func(m *graphMetrics) (float64, bool) { return float64(m.stats.TotalQuantity), true }, true},
+		{"pixela_graph_quantity_max", "The maximum quantity of the graph.", func(m *graphMetrics) (float64, bool) { return float64(m.stats.MaxQuantity), true }, true},
+		{"pixela_graph_quantity_min", "The minimum quantity of the graph.", func(m *graphMetrics) (float64, bool) { return float64(m.stats.MinQuantity), true }, true},
+		{"pixela_graph_quantity_avg", "The average quantity of the graph.", func(m *graphMetrics) (float64, bool) { return m.stats.AvgQuantity, true }, true},
+		{"pixela_graph_quantity_today", "The quantity of today of the graph.", func(m *graphMetrics) (float64, bool) { return float64(m.stats.TodaysQuantity), true }, true},
+		{"pixela_graph_latest_quantity", "The quantity of the latest pixel of the graph.", func(m *graphMetrics) (float64, bool) { return m.latestQuantity, m.hasLatest }, false},
+		{"pixela_graph_latest_pixel_timestamp_seconds", "The start of the date of the latest pixel of the graph in its timezone.", func(m *graphMetrics) (float64, bool) {
+			return float64(m.latestPixelUnixTime), m.hasLatest
+		}, false},
+	}
+
+	for _, g := range gauges {
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		for _, m := range graphs {
+			if g.stats && m.stats == nil {
+				continue
+			}
+			if v, ok := g.value(m); ok {
+				fmt.Fprintf(buf, "%s{user=\"%s\",graph=\"%s\"} %s\n", g.name, escapeLabel(userName), escapeLabel(m.id), formatValue(v))
+			}
+		}
+	}
+}
+
+func writeUp(buf *bytes.Buffer, value float64) {
+	fmt.Fprintf(buf, "# HELP pixela_up Whether the last refresh of the metrics succeeded.\n# TYPE pixela_up gauge\npixela_up %s\n", formatValue(value))
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
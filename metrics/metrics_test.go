@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+)
+
+type apiMock struct {
+	calls    int
+	err      error
+	statsErr map[string]error
+	from     string
+	to       string
+}
+
+func (m *apiMock) graphs() ([]pixela.GraphDefinition, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []pixela.GraphDefinition{{ID: "graph-id", TimeZone: "Asia/Tokyo"}, {ID: "empty"}}, nil
+}
+
+func (m *apiMock) stats(graphID string) (*pixela.Stats, error) {
+	if err := m.statsErr[graphID]; err != nil {
+		return nil, err
+	}
+	if graphID == "empty" {
+		return &pixela.Stats{}, nil
+	}
+	return &pixela.Stats{TotalPixelsCount: 2, MaxQuantity: 5, MinQuantity: 3, TotalQuantity: 8, AvgQuantity: 4, TodaysQuantity: 3}, nil
+}
+
+func (m *apiMock) pixelDates(graphID, from, to string) ([]string, error) {
+	if graphID == "empty" {
+		return nil, nil
+	}
+	m.from, m.to = from, to
+	return []string{"20180915", "20180914"}, nil
+}
+
+func (m *apiMock) quantity(graphID, date string) (string, error) {
+	return "3", nil
+}
+
+func scrape(e *Exporter) string {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestExporter(t *testing.T) {
+	api := &apiMock{}
+	now := time.Date(2018, 9, 15, 20, 0, 0, 0, time.UTC)
+	e := &Exporter{userName: "user", api: api, now: func() time.Time { return now }}
+
+	body := scrape(e)
+	for _, expect := range []string{
+		"pixela_up 1\n",
+		`pixela_graph_pixels{user="user",graph="graph-id"} 2` + "\n",
+		`pixela_graph_quantity_avg{user="user",graph="graph-id"} 4` + "\n",
+		`pixela_graph_quantity_today{user="user",graph="empty"} 0` + "\n",
+		`pixela_graph_latest_quantity{user="user",graph="graph-id"} 3` + "\n",
+		// 20180915 00:00 in Asia/Tokyo.
+		`pixela_graph_latest_pixel_timestamp_seconds{user="user",graph="graph-id"} 1536937200` + "\n",
+	} {
+		if strings.Contains(body, expect) == false {
+			t.Errorf("got: %s\nwant: %s", body, expect)
+		}
+	}
+	if strings.Contains(body, `pixela_graph_latest_quantity{user="user",graph="empty"}`) {
+		t.Errorf("got: %s\nwant: no latest quantity of the empty graph", body)
+	}
+	if api.to != "20180916" {
+		t.Errorf("to: %s\nwant: 20180916", api.to)
+	}
+
+	// Scrapes in the refresh interval do not call the API.
+	now = now.Add(time.Minute)
+	scrape(e)
+	if api.calls != 1 {
+		t.Errorf("calls: %d\nwant: 1", api.calls)
+	}
+
+	api.err = errors.New("unavailable")
+	now = now.Add(defaultRefreshInterval)
+	body = scrape(e)
+	if strings.Contains(body, "pixela_up 0\n") == false || strings.Contains(body, "pixela_graph_pixels{") == false {
+		t.Errorf("got: %s\nwant: pixela_up 0 and the last metrics", body)
+	}
+	if strings.Count(body, "pixela_up") != 3 {
+		t.Errorf("got: %s\nwant: one pixela_up", body)
+	}
+}
+
+func TestExporterGraphFailure(t *testing.T) {
+	api := &apiMock{statsErr: map[string]error{"empty": errors.New("failed to unmarshal json")}}
+	now := time.Date(2018, 9, 15, 20, 0, 0, 0, time.UTC)
+	e := &Exporter{userName: "user", api: api, now: func() time.Time { return now }}
+
+	body := scrape(e)
+	for _, expect := range []string{
+		"pixela_up 1\n",
+		`pixela_graph_up{user="user",graph="graph-id"} 1` + "\n",
+		`pixela_graph_up{user="user",graph="empty"} 0` + "\n",
+		`pixela_graph_pixels{user="user",graph="graph-id"} 2` + "\n",
+	} {
+		if strings.Contains(body, expect) == false {
+			t.Errorf("got: %s\nwant: %s", body, expect)
+		}
+	}
+	if strings.Contains(body, `pixela_graph_pixels{user="user",graph="empty"}`) {
+		t.Errorf("got: %s\nwant: no stats of the failed graph", body)
+	}
+
+	// The failed graph keeps the metrics of the last refresh.
+	api.statsErr = map[string]error{"graph-id": errors.New("unavailable")}
+	now = now.Add(defaultRefreshInterval)
+	body = scrape(e)
+	for _, expect := range []string{
+		`pixela_graph_up{user="user",graph="graph-id"} 0` + "\n",
+		`pixela_graph_up{user="user",graph="empty"} 1` + "\n",
+		`pixela_graph_pixels{user="user",graph="graph-id"} 2` + "\n",
+		`pixela_graph_pixels{user="user",graph="empty"} 0` + "\n",
+	} {
+		if strings.Contains(body, expect) == false {
+			t.Errorf("got: %s\nwant: %s", body, expect)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	actual := escapeLabel("a\"b\\c\nd")
+	expect := `a\"b\\c\nd`
+	if actual != expect {
+		t.Errorf("got: %s\nwant: %s", actual, expect)
+	}
+}
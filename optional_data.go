@@ -0,0 +1,93 @@
+package pixela
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// MaxOptionalDataSize is the maximum size of optional data in bytes that Pixela accepts.
+const MaxOptionalDataSize = 10 * 1024
+
+// ErrPublicOptionalData is returned when optional data is about to be sent to a graph that publishes it
+// and it is not allowed by OptionalDataOptions.AllowPublic.
+var ErrPublicOptionalData = errors.New("optional data of the graph is public")
+
+// OptionalDataValidator is implemented by optional data types that validate themselves before they are sent,
+// for example against a JSON schema.
+type OptionalDataValidator interface {
+	Validate() error
+}
+
+// OptionalDataOptions are the options of CreateWithOptionalData and UpdateWithOptionalData.
+type OptionalDataOptions struct {
+	// AllowPublic allows sending the optional data to a graph whose PublishOptionalData is true.
+	AllowPublic bool
+}
+
+// MarshalOptionalData marshals v into optional data.
+// It fails if v fails to validate itself or the JSON is larger than MaxOptionalDataSize.
+func MarshalOptionalData(v interface{}) (string, error) {
+	if validator, ok := v.(OptionalDataValidator); ok {
+		if err := validator.Validate(); err != nil {
+			return "", errors.Wrap(err, "invalid optional data")
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal json")
+	}
+	if len(b) > MaxOptionalDataSize {
+		return "", errors.Errorf("optional data is too large: %d bytes > %d bytes", len(b), MaxOptionalDataSize)
+	}
+	return string(b), nil
+}
+
+// UnmarshalOptionalData unmarshals the optional data of the quantity into v.
+func (q *Quantity) UnmarshalOptionalData(v interface{}) error {
+	if q.OptionalData == "" {
+		return errors.New("optional data is empty")
+	}
+	return errors.Wrap(json.Unmarshal([]byte(q.OptionalData), v), "failed to unmarshal json")
+}
+
+// CreateWithOptionalData records the quantity of the specified date with v marshaled as the optional data.
+// The graph definition is fetched first, and ErrPublicOptionalData is returned without recording anything
+// if the graph publishes optional data and opts does not allow it.
+func (p *Pixel) CreateWithOptionalData(date, quantity string, v interface{}, opts *OptionalDataOptions) (*Result, error) {
+	optionalData, err := p.checkOptionalData(v, opts)
+	if err != nil {
+		return &Result{}, err
+	}
+	return p.Create(date, quantity, optionalData)
+}
+
+// UpdateWithOptionalData updates the quantity of the specified date with v marshaled as the optional data.
+// It checks the graph definition as CreateWithOptionalData does.
+func (p *Pixel) UpdateWithOptionalData(date, quantity string, v interface{}, opts *OptionalDataOptions) (*Result, error) {
+	optionalData, err := p.checkOptionalData(v, opts)
+	if err != nil {
+		return &Result{}, err
+	}
+	return p.Update(date, quantity, optionalData)
+}
+
+func (p *Pixel) checkOptionalData(v interface{}, opts *OptionalDataOptions) (string, error) {
+	optionalData, err := MarshalOptionalData(v)
+	if err != nil {
+		return "", err
+	}
+
+	if opts == nil || opts.AllowPublic == false {
+		graph := &Graph{UserName: p.UserName, Token: p.Token, GraphID: p.GraphID, client: p.client, state: p.state}
+		definition, err := graph.definition()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get graph definition")
+		}
+		if definition.PublishOptionalData {
+			return "", ErrPublicOptionalData
+		}
+	}
+	return optionalData, nil
+}
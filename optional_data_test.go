@@ -0,0 +1,93 @@
+package pixela
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type testOptionalData struct {
+	Commits []string `json:"commits"`
+}
+
+func (d *testOptionalData) Validate() error {
+	if len(d.Commits) == 0 {
+		return errors.New("commits is empty")
+	}
+	return nil
+}
+
+func newOptionalDataMock(public bool, requests *[]string) *httpClientMock {
+	definitions := testGraphDefinitions
+	if public {
+		definitions = strings.Replace(definitions, `"publishOptionalData":false`, `"publishOptionalData":true`, 1)
+	}
+	return &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		*requests = append(*requests, req.Method+" "+req.URL.Path)
+		if req.Method == http.MethodGet {
+			return http.StatusOK, []byte(definitions)
+		}
+		return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+	}}
+}
+
+func TestMarshalOptionalData(t *testing.T) {
+	actual, err := MarshalOptionalData(&testOptionalData{Commits: []string{"a"}})
+	if err != nil {
+		t.Errorf("got: %v\nwant: nil", err)
+	}
+	expect := `{"commits":["a"]}`
+	if actual != expect {
+		t.Errorf("got: %s\nwant: %s", actual, expect)
+	}
+
+	if _, err := MarshalOptionalData(&testOptionalData{}); err == nil {
+		t.Errorf("got: nil\nwant: validation error")
+	}
+	large := &testOptionalData{Commits: []string{strings.Repeat("a", MaxOptionalDataSize)}}
+	if _, err := MarshalOptionalData(large); err == nil {
+		t.Errorf("got: nil\nwant: size error")
+	}
+}
+
+func TestQuantityUnmarshalOptionalData(t *testing.T) {
+	q := &Quantity{Quantity: "5", OptionalData: `{"commits":["a","b"]}`}
+	var data testOptionalData
+	if err := q.UnmarshalOptionalData(&data); err != nil {
+		t.Errorf("got: %v\nwant: nil", err)
+	}
+	if len(data.Commits) != 2 || data.Commits[1] != "b" {
+		t.Errorf("got: %v\nwant: [a b]", data.Commits)
+	}
+}
+
+func TestPixelCreateWithOptionalData(t *testing.T) {
+	var requests []string
+	clientMock = newOptionalDataMock(false, &requests)
+
+	client := Client{UserName: userName, Token: token}
+	result, err := client.Pixel(graphID).CreateWithOptionalData("20180915", "5", &testOptionalData{Commits: []string{"a"}}, nil)
+	testSuccess(t, result, err)
+	if len(requests) != 2 || requests[1] != "POST /v1/users/user/graphs/graph-id" {
+		t.Errorf("got: %v\nwant: GET and POST", requests)
+	}
+}
+
+func TestPixelUpdateWithOptionalDataPublic(t *testing.T) {
+	var requests []string
+	clientMock = newOptionalDataMock(true, &requests)
+
+	client := Client{UserName: userName, Token: token}
+	data := &testOptionalData{Commits: []string{"a"}}
+	_, err := client.Pixel(graphID).UpdateWithOptionalData("20180915", "5", data, nil)
+	if err != ErrPublicOptionalData {
+		t.Errorf("got: %v\nwant: %v", err, ErrPublicOptionalData)
+	}
+	if len(requests) != 1 {
+		t.Errorf("got: %v\nwant: only GET", requests)
+	}
+
+	result, err := client.Pixel(graphID).UpdateWithOptionalData("20180915", "5", data, &OptionalDataOptions{AllowPublic: true})
+	testSuccess(t, result, err)
+}
@@ -9,20 +9,33 @@ import (
 )
 
 // A Pixel manages communication with the Pixela pixel API.
+// If it is returned by Client.Pixel, it uses the current credentials of the Client
+// instead of UserName and Token, so that a token rotated by Client.UpdateUser reaches it.
 type Pixel struct {
 	UserName string
 	Token    string
 	GraphID  string
+
+	client *Client
+	state  *credentialState
 }
 
 // Create records the quantity of the specified date as a "Pixel".
 func (p *Pixel) Create(date string, quantity, optionalData string) (*Result, error) {
+	if err := validate(ValidateUserName(p.userName()), ValidateGraphID(p.GraphID), ValidateDate(date)); err != nil {
+		return &Result{}, err
+	}
+
 	param, err := p.createCreateRequestParameter(date, quantity, optionalData)
 	if err != nil {
 		return &Result{}, errors.Wrapf(err, "failed to create pixel create parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := p.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		p.client.invalidateGraph(p.userName(), p.GraphID, false)
+	}
+	return result, err
 }
 
 func (p *Pixel) createCreateRequestParameter(date, quantity, optionalData string) (*requestParameter, error) {
@@ -33,10 +46,11 @@ func (p *Pixel) createCreateRequestParameter(date, quantity, optionalData string
 	}
 
 	return &requestParameter{
-		Method: http.MethodPost,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s", p.UserName, p.GraphID),
-		Header: map[string]string{userToken: p.Token},
-		Body:   b,
+		Operation: "Pixel.Create",
+		Method:    http.MethodPost,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s", p.userName(), p.GraphID),
+		Header:    map[string]string{userToken: p.token()},
+		Body:      b,
 	}, nil
 }
 
@@ -54,15 +68,20 @@ func (p *Pixel) Increment() (*Result, error) {
 		return &Result{}, errors.Wrapf(err, "failed to create pixel increment parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := p.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		p.client.invalidateGraph(p.userName(), p.GraphID, false)
+	}
+	return result, err
 }
 
 func (p *Pixel) createIncrementRequestParameter() (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodPut,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/increment", p.UserName, p.GraphID),
-		Header: map[string]string{contentLength: "0", userToken: p.Token},
-		Body:   []byte{},
+		Operation: "Pixel.Increment",
+		Method:    http.MethodPut,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/increment", p.userName(), p.GraphID),
+		Header:    map[string]string{contentLength: "0", userToken: p.token()},
+		Body:      []byte{},
 	}, nil
 }
 
@@ -74,45 +93,93 @@ func (p *Pixel) Decrement() (*Result, error) {
 		return &Result{}, errors.Wrapf(err, "failed to create pixel decrement parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := p.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		p.client.invalidateGraph(p.userName(), p.GraphID, false)
+	}
+	return result, err
 }
 
 func (p *Pixel) createDecrementRequestParameter() (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodPut,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/decrement", p.UserName, p.GraphID),
-		Header: map[string]string{contentLength: "0", userToken: p.Token},
-		Body:   []byte{},
+		Operation: "Pixel.Decrement",
+		Method:    http.MethodPut,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/decrement", p.userName(), p.GraphID),
+		Header:    map[string]string{contentLength: "0", userToken: p.token()},
+		Body:      []byte{},
 	}, nil
 }
 
+// Add adds quantity to the "Pixel" of the day (it is used "timezone" setting if Graph's "timezone" is specified, if not specified, calculates it in "UTC").
+func (p *Pixel) Add(quantity string) (*Result, error) {
+	param, err := p.createAddRequestParameter(quantity)
+	if err != nil {
+		return &Result{}, errors.Wrapf(err, "failed to create pixel add parameter")
+	}
+
+	result, err := p.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		p.client.invalidateGraph(p.userName(), p.GraphID, false)
+	}
+	return result, err
+}
+
+func (p *Pixel) createAddRequestParameter(quantity string) (*requestParameter, error) {
+	add := pixelAdd{Quantity: quantity}
+	b, err := json.Marshal(add)
+	if err != nil {
+		return &requestParameter{}, errors.Wrap(err, "failed to marshal json")
+	}
+
+	return &requestParameter{
+		Operation: "Pixel.Add",
+		Method:    http.MethodPut,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/add", p.userName(), p.GraphID),
+		Header:    map[string]string{userToken: p.token()},
+		Body:      b,
+	}, nil
+}
+
+type pixelAdd struct {
+	Quantity string `json:"quantity"`
+}
+
 // Get gets registered quantity as "Pixel".
+// The StatusCode of the result is http.StatusNotFound if the pixel or the graph does not exist.
 func (p *Pixel) Get(date string) (*Quantity, error) {
+	return p.get(date, true)
+}
+
+// get gets the quantity, from the cache if cached is true, and keeps the status code of the response.
+func (p *Pixel) get(date string, cached bool) (*Quantity, error) {
 	param, err := p.createGetRequestParameter(date)
 	if err != nil {
 		return &Quantity{}, errors.Wrapf(err, "failed to create pixel get parameter")
 	}
 
-	b, err := doRequest(param)
-	if err != nil {
-		return &Quantity{}, errors.Wrapf(err, "failed to do request")
-	}
-
 	var quantity Quantity
-	if err := json.Unmarshal(b, &quantity); err != nil {
-		return &Quantity{}, errors.Wrapf(err, "failed to unmarshal json")
+	var statusCode int
+	if cached {
+		statusCode, err = p.client.cachedDecode(p.userName(), pixelTTL, param, &quantity)
+	} else {
+		statusCode, err = p.client.doRequestAndDecode(param, &quantity)
+	}
+	if err != nil {
+		return &Quantity{}, err
 	}
 
 	quantity.IsSuccess = quantity.Message == ""
+	quantity.StatusCode = statusCode
 	return &quantity, nil
 }
 
 func (p *Pixel) createGetRequestParameter(date string) (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodGet,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/%s", p.UserName, p.GraphID, date),
-		Header: map[string]string{userToken: p.Token},
-		Body:   []byte{},
+		Operation: "Pixel.Get",
+		Method:    http.MethodGet,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/%s", p.userName(), p.GraphID, date),
+		Header:    map[string]string{userToken: p.token()},
+		Body:      []byte{},
 	}, nil
 }
 
@@ -130,7 +197,11 @@ func (p *Pixel) Update(date, quantity, optionalData string) (*Result, error) {
 		return &Result{}, errors.Wrapf(err, "failed to create pixel update parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := p.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		p.client.invalidateGraph(p.userName(), p.GraphID, false)
+	}
+	return result, err
 }
 
 func (p *Pixel) createUpdateRequestParameter(date, quantity, optionalData string) (*requestParameter, error) {
@@ -141,10 +212,11 @@ func (p *Pixel) createUpdateRequestParameter(date, quantity, optionalData string
 	}
 
 	return &requestParameter{
-		Method: http.MethodPut,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/%s", p.UserName, p.GraphID, date),
-		Header: map[string]string{userToken: p.Token},
-		Body:   b,
+		Operation: "Pixel.Update",
+		Method:    http.MethodPut,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/%s", p.userName(), p.GraphID, date),
+		Header:    map[string]string{userToken: p.token()},
+		Body:      b,
 	}, nil
 }
 
@@ -160,14 +232,27 @@ func (p *Pixel) Delete(date string) (*Result, error) {
 		return &Result{}, errors.Wrapf(err, "failed to create pixel delete parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := p.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		p.client.invalidateGraph(p.userName(), p.GraphID, false)
+	}
+	return result, err
 }
 
 func (p *Pixel) createDeleteRequestParameter(date string) (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodDelete,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/%s", p.UserName, p.GraphID, date),
-		Header: map[string]string{userToken: p.Token},
-		Body:   []byte{},
+		Operation: "Pixel.Delete",
+		Method:    http.MethodDelete,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/%s", p.userName(), p.GraphID, date),
+		Header:    map[string]string{userToken: p.token()},
+		Body:      []byte{},
 	}, nil
 }
+
+func (p *Pixel) userName() string {
+	return p.state.userNameOr(p.UserName)
+}
+
+func (p *Pixel) token() string {
+	return p.state.tokenOr(p.Token)
+}
@@ -9,7 +9,7 @@ import (
 
 func TestCreatePixelCreateRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Pixel(graphID).createCreateRequestParameter("20180915", "5", "{\"key\":\"value\"}")
+	param, err := client.pixel(graphID).createCreateRequestParameter("20180915", "5", "{\"key\":\"value\"}")
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -63,7 +63,7 @@ func TestPixelCreateError(t *testing.T) {
 
 func TestCreatePixelIncrementRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Pixel(graphID).createIncrementRequestParameter()
+	param, err := client.pixel(graphID).createIncrementRequestParameter()
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -116,9 +116,53 @@ func TestPixelIncrementError(t *testing.T) {
 	testPageNotFoundError(t, err)
 }
 
+func TestCreatePixelAddRequestParameter(t *testing.T) {
+	client := Client{UserName: userName, Token: token}
+	param, err := client.pixel(graphID).createAddRequestParameter("5")
+	if err != nil {
+		t.Errorf("got: %v\nwant: nil", err)
+	}
+
+	if param.Method != http.MethodPut {
+		t.Errorf("request method: %s\nwant: %s", param.Method, http.MethodPut)
+	}
+
+	expect := fmt.Sprintf(APIBaseURL+"/users/%s/graphs/%s/add", userName, graphID)
+	if param.URL != expect {
+		t.Errorf("URL: %s\nwant: %s", param.URL, expect)
+	}
+
+	if param.Header[userToken] != token {
+		t.Errorf("%s: %s\nwant: %s", userToken, param.Header[userToken], token)
+	}
+
+	s := `{"quantity":"5"}`
+	if bytes.Equal(param.Body, []byte(s)) == false {
+		t.Errorf("Body: %s\nwant: %s", string(param.Body), s)
+	}
+}
+
+func TestPixelAdd(t *testing.T) {
+	clientMock = newOKMock()
+
+	client := Client{UserName: userName, Token: token}
+	result, err := client.Pixel(graphID).Add("5")
+
+	testSuccess(t, result, err)
+}
+
+func TestPixelAddFail(t *testing.T) {
+	clientMock = newAPIFailedMock()
+
+	client := Client{UserName: userName, Token: token}
+	result, err := client.Pixel(graphID).Add("5")
+
+	testAPIFailedResult(t, result, err)
+}
+
 func TestCreatePixelDecrementRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Pixel(graphID).createDecrementRequestParameter()
+	param, err := client.pixel(graphID).createDecrementRequestParameter()
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -169,7 +213,7 @@ func TestPixelDecrementError(t *testing.T) {
 
 func TestCreatePixelGetRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Pixel(graphID).createGetRequestParameter("20180915")
+	param, err := client.pixel(graphID).createGetRequestParameter("20180915")
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -206,7 +250,7 @@ func TestPixelGet(t *testing.T) {
 	expect := &Quantity{
 		Quantity:     "5",
 		OptionalData: "{\"key\":\"value\"}",
-		Result:       Result{IsSuccess: true},
+		Result:       Result{IsSuccess: true, StatusCode: http.StatusOK},
 	}
 	if *quantity != *expect {
 		t.Errorf("got: %v\nwant: %v", quantity, expect)
@@ -233,7 +277,7 @@ func TestPixelGetError(t *testing.T) {
 
 func TestCreatePixelUpdateRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Pixel(graphID).createUpdateRequestParameter("20180915", "5", "{\"key\":\"value\"}")
+	param, err := client.pixel(graphID).createUpdateRequestParameter("20180915", "5", "{\"key\":\"value\"}")
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -287,7 +331,7 @@ func TestPixelUpdateError(t *testing.T) {
 
 func TestCreatePixelDeleteRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Pixel(graphID).createDeleteRequestParameter("20180915")
+	param, err := client.pixel(graphID).createDeleteRequestParameter("20180915")
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
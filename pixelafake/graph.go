@@ -0,0 +1,266 @@
+package pixelafake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/ebc-2in2crc/pixela-client-go/render"
+)
+
+// Graph is a fake of pixela.GraphService.
+type Graph struct {
+	store   *Store
+	graphID string
+}
+
+// Create creates the graph definition.
+func (g *Graph) Create(name, unit, quantityType, color, timezone, selfSufficient string, isSecret, publishOptionalData bool) (*pixela.Result, error) {
+	if err := pixela.ValidateGraphID(g.graphID); err != nil {
+		return &pixela.Result{}, err
+	}
+	if quantityType != pixela.TypeInt && quantityType != pixela.TypeFloat {
+		return failure("Specified type is invalid."), nil
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return failure("Specified timezone is invalid."), nil
+	}
+	if selfSufficient == "" {
+		selfSufficient = pixela.SelfSufficientNone
+	}
+
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+	if g.store.graph(g.graphID) != nil {
+		return failure("This graph ID is already used."), nil
+	}
+	g.store.graphs = append(g.store.graphs, &graph{
+		definition: pixela.GraphDefinition{
+			ID:                  g.graphID,
+			Name:                name,
+			Unit:                unit,
+			Type:                quantityType,
+			Color:               color,
+			TimeZone:            timezone,
+			PurgeCacheURLs:      []string{},
+			SelfSufficient:      selfSufficient,
+			IsSecret:            isSecret,
+			PublishOptionalData: publishOptionalData,
+		},
+		pixels: map[string]pixela.PixelValue{},
+	})
+	return success(), nil
+}
+
+// GetAll returns all graph definitions in the order they were created.
+func (g *Graph) GetAll() (*pixela.GraphDefinitions, error) {
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	definitions := &pixela.GraphDefinitions{Graphs: []pixela.GraphDefinition{}, Result: pixela.Result{IsSuccess: true}}
+	for _, graph := range g.store.graphs {
+		definitions.Graphs = append(definitions.Graphs, graph.definition)
+	}
+	return definitions, nil
+}
+
+// GetSVG renders the graph with the render package.
+func (g *Graph) GetSVG(date, mode string) (string, error) {
+	definition, pixels, ok := g.snapshot()
+	if ok == false {
+		return "", fmt.Errorf("failed to call API: %s", graphNotFound(g.graphID).Message)
+	}
+
+	var buf bytes.Buffer
+	if err := render.SVG(&buf, &definition, pixels, &render.Options{Mode: mode, Date: date}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// GetSVGConditional renders the graph. It is never unchanged because there are no validators.
+func (g *Graph) GetSVGConditional(date, mode string) (*pixela.SVG, error) {
+	body, err := g.GetSVG(date, mode)
+	if err != nil {
+		return &pixela.SVG{}, err
+	}
+	return &pixela.SVG{Body: body}, nil
+}
+
+// URL returns the same URL as pixela.Graph.URL.
+func (g *Graph) URL(mode string) string {
+	graph := &pixela.Graph{UserName: g.store.UserName, GraphID: g.graphID}
+	return graph.URL(mode)
+}
+
+// GraphsURL returns the same URL as pixela.Graph.GraphsURL.
+func (g *Graph) GraphsURL() string {
+	graph := &pixela.Graph{UserName: g.store.UserName}
+	return graph.GraphsURL()
+}
+
+// Stats returns the statistics of the pixels.
+func (g *Graph) Stats() (*pixela.Stats, error) {
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	graph := g.store.graph(g.graphID)
+	if graph == nil {
+		return &pixela.Stats{Result: *graphNotFound(g.graphID)}, nil
+	}
+
+	stats := &pixela.Stats{Result: pixela.Result{IsSuccess: true}}
+	var total float64
+	for date, p := range graph.pixels {
+		q, _ := parseQuantity(p.Quantity, graph.definition.Type)
+		if stats.TotalPixelsCount == 0 || int(q) > stats.MaxQuantity {
+			stats.MaxQuantity = int(q)
+		}
+		if stats.TotalPixelsCount == 0 || int(q) < stats.MinQuantity {
+			stats.MinQuantity = int(q)
+		}
+		if date == g.store.today(graph) {
+			stats.TodaysQuantity = int(q)
+		}
+		stats.TotalPixelsCount++
+		total += q
+	}
+	stats.TotalQuantity = int(total)
+	if stats.TotalPixelsCount > 0 {
+		stats.AvgQuantity = math.Round(total/float64(stats.TotalPixelsCount)*100) / 100
+	}
+	return stats, nil
+}
+
+// Update updates the graph definition.
+func (g *Graph) Update(name, unit, color, timezone string, purgeCacheUrls []string, selfSufficient string, isSecret bool, publishOptionalData bool) (*pixela.Result, error) {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return failure("Specified timezone is invalid."), nil
+		}
+	}
+
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	graph := g.store.graph(g.graphID)
+	if graph == nil {
+		return graphNotFound(g.graphID), nil
+	}
+	d := &graph.definition
+	d.Name, d.Unit, d.Color = name, unit, color
+	if timezone != "" {
+		d.TimeZone = timezone
+	}
+	if purgeCacheUrls != nil {
+		d.PurgeCacheURLs = purgeCacheUrls
+	}
+	if selfSufficient != "" {
+		d.SelfSufficient = selfSufficient
+	}
+	d.IsSecret, d.PublishOptionalData = isSecret, publishOptionalData
+	return success(), nil
+}
+
+// Delete deletes the graph and its webhooks.
+func (g *Graph) Delete() (*pixela.Result, error) {
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	for i, graph := range g.store.graphs {
+		if graph.definition.ID == g.graphID {
+			g.store.graphs = append(g.store.graphs[:i], g.store.graphs[i+1:]...)
+			webhooks := g.store.webhooks[:0]
+			for _, w := range g.store.webhooks {
+				if w.GraphID != g.graphID {
+					webhooks = append(webhooks, w)
+				}
+			}
+			g.store.webhooks = webhooks
+			return success(), nil
+		}
+	}
+	return graphNotFound(g.graphID), nil
+}
+
+// GetPixelDates returns the dates of the pixels in the period as pixela.Graph.GetPixelDates describes.
+func (g *Graph) GetPixelDates(from, to string) (*pixela.Pixels, error) {
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	graph := g.store.graph(g.graphID)
+	if graph == nil {
+		return &pixela.Pixels{Result: *graphNotFound(g.graphID)}, nil
+	}
+
+	start, end, ok := period(from, to, g.store.today(graph))
+	if ok == false {
+		return &pixela.Pixels{Result: *failure("Specified period is invalid.")}, nil
+	}
+	pixels := &pixela.Pixels{Pixels: []string{}, Result: pixela.Result{IsSuccess: true}}
+	for _, date := range sortedDates(graph.pixels) {
+		if date >= start && date <= end {
+			pixels.Pixels = append(pixels.Pixels, date)
+		}
+	}
+	return pixels, nil
+}
+
+func period(from, to, today string) (string, string, bool) {
+	parse := func(s string) (time.Time, bool) {
+		t, err := time.Parse(dateFormat, s)
+		return t, err == nil
+	}
+	switch {
+	case from == "" && to == "":
+		t, _ := parse(today)
+		return t.AddDate(0, 0, -365).Format(dateFormat), today, true
+	case to == "":
+		t, ok := parse(from)
+		return from, t.AddDate(0, 0, 365).Format(dateFormat), ok
+	case from == "":
+		t, ok := parse(to)
+		return t.AddDate(0, 0, -365).Format(dateFormat), to, ok
+	default:
+		f, ok1 := parse(from)
+		t, ok2 := parse(to)
+		return from, to, ok1 && ok2 && t.Sub(f) <= 365*24*time.Hour && f.After(t) == false
+	}
+}
+
+// Export is pixela.ExportGraph of the fakes.
+func (g *Graph) Export(ctx context.Context, w io.Writer, format string) error {
+	return pixela.ExportGraph(ctx, g, g.store.Pixel(g.graphID), g.graphID, w, format)
+}
+
+// Import is pixela.ImportGraph of the fakes.
+func (g *Graph) Import(ctx context.Context, r io.Reader, format string, dryRun bool) (*pixela.ImportDiff, error) {
+	return pixela.ImportGraph(ctx, g, g.store.Pixel(g.graphID), g.graphID, r, format, dryRun)
+}
+
+// Sync is pixela.SyncGraph of the fakes.
+func (g *Graph) Sync(ctx context.Context, source pixela.Source, opts *pixela.SyncOptions) (*pixela.SyncDiff, error) {
+	return pixela.SyncGraph(ctx, g, g.store.Pixel(g.graphID), g.graphID, source, opts)
+}
+
+func (g *Graph) snapshot() (pixela.GraphDefinition, []pixela.PixelValue, bool) {
+	g.store.mu.Lock()
+	defer g.store.mu.Unlock()
+
+	graph := g.store.graph(g.graphID)
+	if graph == nil {
+		return pixela.GraphDefinition{}, nil, false
+	}
+	var pixels []pixela.PixelValue
+	for _, date := range sortedDates(graph.pixels) {
+		pixels = append(pixels, graph.pixels[date])
+	}
+	return graph.definition, pixels, true
+}
@@ -0,0 +1,153 @@
+package pixelafake
+
+import (
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+)
+
+// Pixel is a fake of pixela.PixelService.
+type Pixel struct {
+	store   *Store
+	graphID string
+}
+
+// Create records the quantity of the date.
+func (p *Pixel) Create(date string, quantity, optionalData string) (*pixela.Result, error) {
+	if err := pixela.ValidateDate(date); err != nil {
+		return &pixela.Result{}, err
+	}
+	return p.set(date, quantity, optionalData), nil
+}
+
+// CreateWithOptionalData records the quantity of the date with v as the optional data.
+func (p *Pixel) CreateWithOptionalData(date, quantity string, v interface{}, opts *pixela.OptionalDataOptions) (*pixela.Result, error) {
+	optionalData, err := p.checkOptionalData(v, opts)
+	if err != nil {
+		return &pixela.Result{}, err
+	}
+	return p.Create(date, quantity, optionalData)
+}
+
+// Increment adds 1, or 0.01 for a float graph, to the pixel of today.
+func (p *Pixel) Increment() (*pixela.Result, error) {
+	return p.add(1, 0.01), nil
+}
+
+// Decrement adds -1, or -0.01 for a float graph, to the pixel of today.
+func (p *Pixel) Decrement() (*pixela.Result, error) {
+	return p.add(-1, -0.01), nil
+}
+
+// Add adds the quantity to the pixel of today.
+func (p *Pixel) Add(quantity string) (*pixela.Result, error) {
+	p.store.mu.Lock()
+	graph := p.store.graph(p.graphID)
+	p.store.mu.Unlock()
+	if graph == nil {
+		return graphNotFound(p.graphID), nil
+	}
+
+	q, ok := parseQuantity(quantity, graph.definition.Type)
+	if ok == false {
+		return failure("Specified quantity is invalid."), nil
+	}
+	return p.add(q, q), nil
+}
+
+// Get returns the quantity of the date.
+func (p *Pixel) Get(date string) (*pixela.Quantity, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	graph := p.store.graph(p.graphID)
+	if graph == nil {
+		return &pixela.Quantity{Result: *graphNotFound(p.graphID)}, nil
+	}
+	pixel, ok := graph.pixels[date]
+	if ok == false {
+		return &pixela.Quantity{Result: *notFound("Specified pixel not found.")}, nil
+	}
+	return &pixela.Quantity{Quantity: pixel.Quantity, OptionalData: pixel.OptionalData, Result: pixela.Result{IsSuccess: true}}, nil
+}
+
+// Update records the quantity of the date.
+func (p *Pixel) Update(date, quantity, optionalData string) (*pixela.Result, error) {
+	return p.set(date, quantity, optionalData), nil
+}
+
+// UpdateWithOptionalData records the quantity of the date with v as the optional data.
+func (p *Pixel) UpdateWithOptionalData(date, quantity string, v interface{}, opts *pixela.OptionalDataOptions) (*pixela.Result, error) {
+	optionalData, err := p.checkOptionalData(v, opts)
+	if err != nil {
+		return &pixela.Result{}, err
+	}
+	return p.Update(date, quantity, optionalData)
+}
+
+// Delete deletes the pixel of the date.
+func (p *Pixel) Delete(date string) (*pixela.Result, error) {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	graph := p.store.graph(p.graphID)
+	if graph == nil {
+		return graphNotFound(p.graphID), nil
+	}
+	if _, ok := graph.pixels[date]; ok == false {
+		return failure("Specified pixel not found."), nil
+	}
+	delete(graph.pixels, date)
+	return success(), nil
+}
+
+func (p *Pixel) set(date, quantity, optionalData string) *pixela.Result {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	graph := p.store.graph(p.graphID)
+	if graph == nil {
+		return graphNotFound(p.graphID)
+	}
+	if _, ok := parseQuantity(quantity, graph.definition.Type); ok == false {
+		return failure("Specified quantity is invalid.")
+	}
+	if len(optionalData) > pixela.MaxOptionalDataSize {
+		return failure("Specified optionalData is too large.")
+	}
+	graph.pixels[date] = pixela.PixelValue{Date: date, Quantity: quantity, OptionalData: optionalData}
+	return success()
+}
+
+func (p *Pixel) add(intDelta, floatDelta float64) *pixela.Result {
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	graph := p.store.graph(p.graphID)
+	if graph == nil {
+		return graphNotFound(p.graphID)
+	}
+	delta := intDelta
+	if graph.definition.Type == pixela.TypeFloat {
+		delta = floatDelta
+	}
+
+	today := p.store.today(graph)
+	pixel := graph.pixels[today]
+	q, _ := parseQuantity(pixel.Quantity, graph.definition.Type)
+	graph.pixels[today] = pixela.PixelValue{Date: today, Quantity: formatQuantity(q+delta, graph.definition.Type), OptionalData: pixel.OptionalData}
+	return success()
+}
+
+func (p *Pixel) checkOptionalData(v interface{}, opts *pixela.OptionalDataOptions) (string, error) {
+	optionalData, err := pixela.MarshalOptionalData(v)
+	if err != nil {
+		return "", err
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+	graph := p.store.graph(p.graphID)
+	if graph != nil && graph.definition.PublishOptionalData && (opts == nil || opts.AllowPublic == false) {
+		return "", pixela.ErrPublicOptionalData
+	}
+	return optionalData, nil
+}
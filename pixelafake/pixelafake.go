@@ -0,0 +1,175 @@
+// Package pixelafake provides fakes of the Pixela services backed by an in-memory store,
+// so that code depending on pixela.GraphService, pixela.PixelService, pixela.WebhookService and
+// pixela.UserService can be tested without the Pixela API.
+//
+// Store has the same accessors as pixela.Client, so code can depend on an interface such as
+//
+//	type services interface {
+//		Graph(graphID string) pixela.GraphService
+//		Pixel(graphID string) pixela.PixelService
+//	}
+//
+// and be given either a *pixela.Client or a *pixelafake.Store.
+package pixelafake
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+)
+
+const dateFormat = "20060102"
+
+var (
+	_ pixela.GraphService   = (*Graph)(nil)
+	_ pixela.PixelService   = (*Pixel)(nil)
+	_ pixela.WebhookService = (*Webhook)(nil)
+	_ pixela.UserService    = (*Store)(nil)
+)
+
+// Store is the in-memory state of a Pixela user. It is safe for concurrent use.
+type Store struct {
+	UserName string
+	Token    string
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	graphs   []*graph
+	webhooks []pixela.WebhookDefinition
+	nextHash int
+}
+
+type graph struct {
+	definition pixela.GraphDefinition
+	pixels     map[string]pixela.PixelValue
+}
+
+// NewStore returns a new empty Store of the user.
+func NewStore(userName, token string) *Store {
+	return &Store{UserName: userName, Token: token}
+}
+
+// Graph returns a fake of the graph API.
+func (s *Store) Graph(graphID string) pixela.GraphService {
+	return &Graph{store: s, graphID: graphID}
+}
+
+// Pixel returns a fake of the pixel API.
+func (s *Store) Pixel(graphID string) pixela.PixelService {
+	return &Pixel{store: s, graphID: graphID}
+}
+
+// Webhook returns a fake of the webhook API.
+func (s *Store) Webhook() pixela.WebhookService {
+	return &Webhook{store: s}
+}
+
+// CreateUser succeeds if the user name and the token are valid.
+func (s *Store) CreateUser(agreeTermsOfService, notMinor bool, thanksCode string) (*pixela.Result, error) {
+	if err := pixela.ValidateUserName(s.UserName); err != nil {
+		return &pixela.Result{}, err
+	}
+	if err := pixela.ValidateToken(s.Token); err != nil {
+		return &pixela.Result{}, err
+	}
+	if agreeTermsOfService == false || notMinor == false {
+		return failure("Please agree to the terms of service and confirm that you are not a minor."), nil
+	}
+	return success(), nil
+}
+
+// UpdateUser changes the token.
+func (s *Store) UpdateUser(newToken, thanksCode string) (*pixela.Result, error) {
+	if err := pixela.ValidateToken(newToken); err != nil {
+		return &pixela.Result{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Token = newToken
+	return success(), nil
+}
+
+// DeleteUser deletes all graphs and webhooks.
+func (s *Store) DeleteUser() (*pixela.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.graphs = nil
+	s.webhooks = nil
+	return success(), nil
+}
+
+func (s *Store) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// graph returns the graph. s.mu must be held.
+func (s *Store) graph(graphID string) *graph {
+	for _, g := range s.graphs {
+		if g.definition.ID == graphID {
+			return g
+		}
+	}
+	return nil
+}
+
+// today returns today in the timezone of the graph.
+func (s *Store) today(g *graph) string {
+	location, err := time.LoadLocation(g.definition.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	return s.now().In(location).Format(dateFormat)
+}
+
+func success() *pixela.Result {
+	return &pixela.Result{Message: "Success.", IsSuccess: true}
+}
+
+func failure(message string) *pixela.Result {
+	return &pixela.Result{Message: message, IsSuccess: false}
+}
+
+func notFound(message string) *pixela.Result {
+	return &pixela.Result{Message: message, IsSuccess: false, StatusCode: http.StatusNotFound}
+}
+
+func graphNotFound(graphID string) *pixela.Result {
+	return notFound(fmt.Sprintf("Specified graph %s is not found.", graphID))
+}
+
+func parseQuantity(quantity, quantityType string) (float64, bool) {
+	if quantityType == pixela.TypeInt {
+		n, err := strconv.Atoi(quantity)
+		return float64(n), err == nil
+	}
+	f, err := strconv.ParseFloat(quantity, 64)
+	return f, err == nil
+}
+
+func formatQuantity(q float64, quantityType string) string {
+	if quantityType == pixela.TypeInt {
+		return strconv.Itoa(int(q))
+	}
+	return strconv.FormatFloat(math.Round(q*1e8)/1e8, 'f', -1, 64)
+}
+
+func sortedDates(pixels map[string]pixela.PixelValue) []string {
+	dates := make([]string, 0, len(pixels))
+	for date := range pixels {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates
+}
@@ -0,0 +1,163 @@
+package pixelafake
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+)
+
+// services is what consumers depend on instead of *pixela.Client.
+type services interface {
+	Graph(graphID string) pixela.GraphService
+	Pixel(graphID string) pixela.PixelService
+	Webhook() pixela.WebhookService
+}
+
+var (
+	_ services = (*pixela.Client)(nil)
+	_ services = (*Store)(nil)
+)
+
+func newTestStore(t *testing.T) *Store {
+	s := NewStore("user", "secret-token")
+	s.Now = func() time.Time { return time.Date(2018, 9, 15, 20, 0, 0, 0, time.UTC) }
+	result, err := s.Graph("graph-id").Create("name", "commit", pixela.TypeInt, pixela.ColorShibafu, "Asia/Tokyo", "", false, false)
+	if err != nil || result.IsSuccess == false {
+		t.Fatalf("got: %v, %v\nwant: success", result, err)
+	}
+	return s
+}
+
+func TestPixel(t *testing.T) {
+	s := newTestStore(t)
+	pixel := s.Pixel("graph-id")
+
+	pixel.Create("20180914", "5", `{"a":1}`)
+	pixel.Increment()
+	pixel.Add("3")
+
+	q, _ := pixel.Get("20180916")
+	if q.IsSuccess == false || q.Quantity != "4" {
+		t.Errorf("got: %v\nwant: 4 on today in Asia/Tokyo", q)
+	}
+	q, _ = pixel.Get("20180914")
+	if q.Quantity != "5" || q.OptionalData != `{"a":1}` {
+		t.Errorf("got: %v\nwant: 5 with optional data", q)
+	}
+
+	result, _ := pixel.Create("20180913", "1.5", "")
+	if result.IsSuccess {
+		t.Errorf("got: success\nwant: invalid quantity of int graph")
+	}
+	result, _ = pixel.Delete("20180914")
+	if result.IsSuccess == false {
+		t.Errorf("got: %v\nwant: success", result)
+	}
+	q, _ = pixel.Get("20180914")
+	if q.IsSuccess {
+		t.Errorf("got: %v\nwant: not found", q)
+	}
+}
+
+func TestGraph(t *testing.T) {
+	s := newTestStore(t)
+	s.Pixel("graph-id").Create("20180914", "5", "")
+	s.Pixel("graph-id").Create("20180916", "3", "")
+
+	stats, _ := s.Graph("graph-id").Stats()
+	expect := &pixela.Stats{TotalPixelsCount: 2, MaxQuantity: 5, MinQuantity: 3, TotalQuantity: 8, AvgQuantity: 4, TodaysQuantity: 3, Result: pixela.Result{IsSuccess: true}}
+	if reflect.DeepEqual(stats, expect) == false {
+		t.Errorf("got: %v\nwant: %v", stats, expect)
+	}
+
+	pixels, _ := s.Graph("graph-id").GetPixelDates("20180901", "20180915")
+	if reflect.DeepEqual(pixels.Pixels, []string{"20180914"}) == false {
+		t.Errorf("got: %v\nwant: [20180914]", pixels.Pixels)
+	}
+
+	svg, err := s.Graph("graph-id").GetSVG("20180916", pixela.ModeShort)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	values, _ := pixela.ParseSVG(strings.NewReader(svg))
+	if values[len(values)-1] != (pixela.PixelValue{Date: "20180916", Quantity: "3"}) {
+		t.Errorf("got: %v\nwant: the last pixel 20180916", values[len(values)-1])
+	}
+
+	result, _ := s.Graph("graph-id").Create("name", "commit", pixela.TypeInt, pixela.ColorShibafu, "", "", false, false)
+	if result.IsSuccess {
+		t.Errorf("got: success\nwant: duplicate graph ID")
+	}
+}
+
+func TestGraphExportAndSync(t *testing.T) {
+	s := newTestStore(t)
+	s.Pixel("graph-id").Create("20180914", "5", "")
+
+	var buf bytes.Buffer
+	if err := s.Graph("graph-id").Export(context.Background(), &buf, pixela.FormatJSONLines); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	if strings.Contains(buf.String(), `{"date":"20180914","quantity":"5"}`) == false {
+		t.Errorf("got: %s", buf.String())
+	}
+
+	source := pixela.SourceFunc(func(ctx context.Context, location *time.Location) ([]pixela.PixelValue, error) {
+		return []pixela.PixelValue{{Date: "20180915", Quantity: "2"}}, nil
+	})
+	diff, err := s.Graph("graph-id").Sync(context.Background(), source, &pixela.SyncOptions{From: "20180901"})
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	if len(diff.Create) != 1 || len(diff.Delete) != 1 {
+		t.Errorf("got: %v\nwant: 1 create and 1 delete", diff)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	s := newTestStore(t)
+	created, _ := s.Webhook().Create("graph-id", pixela.SelfSufficientDecrement)
+	if created.WebhookHash != "hash-1" {
+		t.Errorf("got: %s\nwant: hash-1", created.WebhookHash)
+	}
+
+	s.Webhook().Invoke("hash-1")
+	q, _ := s.Pixel("graph-id").Get("20180916")
+	if q.Quantity != "-1" {
+		t.Errorf("got: %s\nwant: -1", q.Quantity)
+	}
+
+	s.Graph("graph-id").Delete()
+	webhooks, _ := s.Webhook().GetAll()
+	if len(webhooks.Webhooks) != 0 {
+		t.Errorf("got: %v\nwant: no webhooks of the deleted graph", webhooks.Webhooks)
+	}
+}
+
+func TestPixelOptionalDataPublic(t *testing.T) {
+	s := newTestStore(t)
+	s.Graph("graph-id").Update("name", "commit", pixela.ColorShibafu, "", nil, "", false, true)
+
+	_, err := s.Pixel("graph-id").CreateWithOptionalData("20180915", "1", map[string]int{"a": 1}, nil)
+	if err != pixela.ErrPublicOptionalData {
+		t.Errorf("got: %v\nwant: %v", err, pixela.ErrPublicOptionalData)
+	}
+}
+
+func TestUserInvalid(t *testing.T) {
+	s := NewStore("1user", "secret-token")
+	result, err := s.CreateUser(true, true, "")
+	if err == nil || result == nil || result.IsSuccess {
+		t.Errorf("got: %v, %v\nwant: a failed result and an error", result, err)
+	}
+
+	result, err = s.UpdateUser("short", "")
+	if err == nil || result == nil || result.IsSuccess {
+		t.Errorf("got: %v, %v\nwant: a failed result and an error", result, err)
+	}
+}
@@ -0,0 +1,75 @@
+package pixelafake
+
+import (
+	"fmt"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+)
+
+// Webhook is a fake of pixela.WebhookService.
+type Webhook struct {
+	store *Store
+}
+
+// Create creates a webhook of the graph. Its hash is "hash-1", "hash-2" and so on.
+func (w *Webhook) Create(graphID, selfSufficient string) (*pixela.WebhookCreateResult, error) {
+	if selfSufficient != pixela.SelfSufficientIncrement && selfSufficient != pixela.SelfSufficientDecrement {
+		return &pixela.WebhookCreateResult{Result: *failure("Specified type is invalid.")}, nil
+	}
+
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	if w.store.graph(graphID) == nil {
+		return &pixela.WebhookCreateResult{Result: *graphNotFound(graphID)}, nil
+	}
+
+	w.store.nextHash++
+	hash := fmt.Sprintf("hash-%d", w.store.nextHash)
+	w.store.webhooks = append(w.store.webhooks, pixela.WebhookDefinition{WebhookHash: hash, GraphID: graphID, Type: selfSufficient})
+	return &pixela.WebhookCreateResult{WebhookHash: hash, Result: *success()}, nil
+}
+
+// GetAll returns all webhook definitions in the order they were created.
+func (w *Webhook) GetAll() (*pixela.WebhookDefinitions, error) {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+
+	webhooks := append([]pixela.WebhookDefinition{}, w.store.webhooks...)
+	return &pixela.WebhookDefinitions{Webhooks: webhooks, Result: pixela.Result{IsSuccess: true}}, nil
+}
+
+// Delete deletes the webhook.
+func (w *Webhook) Delete(webhookHash string) (*pixela.Result, error) {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+
+	for i, webhook := range w.store.webhooks {
+		if webhook.WebhookHash == webhookHash {
+			w.store.webhooks = append(w.store.webhooks[:i], w.store.webhooks[i+1:]...)
+			return success(), nil
+		}
+	}
+	return failure("Specified webhook is not found."), nil
+}
+
+// Invoke increments or decrements the pixel of today of the graph of the webhook.
+func (w *Webhook) Invoke(webhookHash string) (*pixela.Result, error) {
+	w.store.mu.Lock()
+	var webhook pixela.WebhookDefinition
+	found := false
+	for _, d := range w.store.webhooks {
+		if d.WebhookHash == webhookHash {
+			webhook, found = d, true
+		}
+	}
+	w.store.mu.Unlock()
+	if found == false {
+		return failure("Specified webhook is not found."), nil
+	}
+
+	pixel := w.store.Pixel(webhook.GraphID)
+	if webhook.Type == pixela.SelfSufficientDecrement {
+		return pixel.Decrement()
+	}
+	return pixel.Increment()
+}
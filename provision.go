@@ -0,0 +1,305 @@
+package pixela
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Manifest is the desired state of the graphs and webhooks of a user.
+type Manifest struct {
+	Graphs   []GraphDefinition   `json:"graphs"`
+	Webhooks []WebhookDefinition `json:"webhooks"`
+}
+
+// ReadManifest reads a Manifest written in JSON or YAML. A manifest that starts with { is read as JSON.
+// The webhookHash of webhooks is ignored, a webhook is identified by its graphId and type.
+//
+// To keep the client free of a YAML dependency, only the subset of YAML a manifest needs is supported:
+// block and single-line flow collections, and plain and quoted scalars.
+// Anchors, aliases, tags and multi-line scalars are not supported.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) == false {
+		v, err := parseYAML(b)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal yaml")
+		}
+		if b, err = json.Marshal(v); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal yaml")
+		}
+	}
+
+	var manifest Manifest
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal manifest")
+	}
+	return &manifest, nil
+}
+
+// It is the kind of the change to be applied.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// It is the kind of the resource to be changed.
+const (
+	ResourceGraph   = "graph"
+	ResourceWebhook = "webhook"
+)
+
+// PlanChange is a change to a graph or a webhook.
+type PlanChange struct {
+	Action   string
+	Resource string
+	// Graph is the desired definition to create or update, or the current definition to delete.
+	Graph *GraphDefinition
+	// Webhook is the desired webhook to create, or the current webhook to delete.
+	Webhook *WebhookDefinition
+	// Fields is the names of the fields to update.
+	Fields []string
+}
+
+func (c *PlanChange) String() string {
+	var symbol string
+	switch c.Action {
+	case ActionCreate:
+		symbol = "+"
+	case ActionUpdate:
+		symbol = "~"
+	case ActionDelete:
+		symbol = "-"
+	}
+
+	if c.Resource == ResourceWebhook {
+		return fmt.Sprintf("%s webhook %s %s", symbol, c.Webhook.GraphID, c.Webhook.Type)
+	}
+	if c.Action == ActionUpdate {
+		return fmt.Sprintf("%s graph %s (%s)", symbol, c.Graph.ID, strings.Join(c.Fields, ", "))
+	}
+	return fmt.Sprintf("%s graph %s", symbol, c.Graph.ID)
+}
+
+// Plan is the changes to make the graphs and webhooks of a user match a Manifest.
+type Plan struct {
+	Changes []PlanChange
+}
+
+// Empty reports whether the plan has no change.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+func (p *Plan) String() string {
+	var b strings.Builder
+	count := map[string]int{}
+	for i := range p.Changes {
+		b.WriteString(p.Changes[i].String())
+		b.WriteString("\n")
+		count[p.Changes[i].Action]++
+	}
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete.\n",
+		count[ActionCreate], count[ActionUpdate], count[ActionDelete])
+	return b.String()
+}
+
+// PlanOptions are the options of Client.Plan.
+type PlanOptions struct {
+	// Prune makes the plan delete the graphs and webhooks not in the manifest.
+	// Their pixels are lost, so it is off by default.
+	Prune bool
+}
+
+// Plan compares the manifest with the graphs and webhooks of the user and returns the changes to make them match.
+// Graphs and webhooks not in the manifest are left as they are, unless opts.Prune is true. opts may be nil.
+// The type of a graph can not be updated, so a manifest that changes it is an error.
+func (c *Client) Plan(manifest *Manifest, opts *PlanOptions) (*Plan, error) {
+	if opts == nil {
+		opts = &PlanOptions{}
+	}
+	if err := validateManifest(manifest); err != nil {
+		return nil, err
+	}
+
+	graphs, err := c.Graph("").GetAll()
+	if err := checkResult(&graphs.Result, err); err != nil {
+		return nil, errors.Wrapf(err, "failed to get all graph definitions")
+	}
+	webhooks, err := c.Webhook().GetAll()
+	if err := checkResult(&webhooks.Result, err); err != nil {
+		return nil, errors.Wrapf(err, "failed to get all webhooks definitions")
+	}
+
+	plan := &Plan{}
+	current := make(map[string]GraphDefinition, len(graphs.Graphs))
+	for _, g := range graphs.Graphs {
+		current[g.ID] = g
+	}
+	desired := make(map[string]bool, len(manifest.Graphs))
+	for i := range manifest.Graphs {
+		want := normalizeGraphDefinition(manifest.Graphs[i])
+		desired[want.ID] = true
+
+		have, ok := current[want.ID]
+		if ok == false {
+			plan.Changes = append(plan.Changes, PlanChange{Action: ActionCreate, Resource: ResourceGraph, Graph: &want})
+			continue
+		}
+		if have.Type != want.Type {
+			return nil, errors.Errorf("type of graph %s can not be changed: %s to %s", want.ID, have.Type, want.Type)
+		}
+		if fields := diffGraphDefinition(have, want); len(fields) > 0 {
+			plan.Changes = append(plan.Changes, PlanChange{Action: ActionUpdate, Resource: ResourceGraph, Graph: &want, Fields: fields})
+		}
+	}
+
+	for i := range manifest.Webhooks {
+		want := manifest.Webhooks[i]
+		if _, ok := findWebhook(webhooks.Webhooks, want.GraphID, want.Type); ok == false {
+			want.WebhookHash = ""
+			plan.Changes = append(plan.Changes, PlanChange{Action: ActionCreate, Resource: ResourceWebhook, Webhook: &want})
+		}
+	}
+	if opts.Prune == false {
+		return plan, nil
+	}
+
+	for i := range webhooks.Webhooks {
+		have := webhooks.Webhooks[i]
+		if _, ok := findWebhook(manifest.Webhooks, have.GraphID, have.Type); ok == false {
+			plan.Changes = append(plan.Changes, PlanChange{Action: ActionDelete, Resource: ResourceWebhook, Webhook: &have})
+		}
+	}
+
+	for i := range graphs.Graphs {
+		have := graphs.Graphs[i]
+		if desired[have.ID] == false {
+			plan.Changes = append(plan.Changes, PlanChange{Action: ActionDelete, Resource: ResourceGraph, Graph: &have})
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply applies the changes of the plan in order.
+// Running Plan again with the same manifest after Apply succeeded returns an empty plan.
+func (c *Client) Apply(ctx context.Context, plan *Plan) error {
+	for i := range plan.Changes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		change := &plan.Changes[i]
+		if err := c.applyChange(change); err != nil {
+			return errors.Wrapf(err, "failed to apply: %s", change)
+		}
+	}
+	return nil
+}
+
+func (c *Client) applyChange(change *PlanChange) error {
+	switch change.Resource {
+	case ResourceGraph:
+		d := change.Graph
+		g := c.Graph(d.ID)
+		switch change.Action {
+		case ActionCreate:
+			return checkResult(g.Create(d.Name, d.Unit, d.Type, d.Color, d.TimeZone, d.SelfSufficient, d.IsSecret, d.PublishOptionalData))
+		case ActionUpdate:
+			return checkResult(g.Update(d.Name, d.Unit, d.Color, d.TimeZone, d.PurgeCacheURLs, d.SelfSufficient, d.IsSecret, d.PublishOptionalData))
+		case ActionDelete:
+			return checkResult(g.Delete())
+		}
+	case ResourceWebhook:
+		w := change.Webhook
+		switch change.Action {
+		case ActionCreate:
+			result, err := c.Webhook().Create(w.GraphID, w.Type)
+			return checkResult(&result.Result, err)
+		case ActionDelete:
+			return checkResult(c.Webhook().Delete(w.WebhookHash))
+		}
+	}
+	return errors.Errorf("unsupported change: %s %s", change.Action, change.Resource)
+}
+
+func validateManifest(manifest *Manifest) error {
+	graphs := make(map[string]bool, len(manifest.Graphs))
+	for _, g := range manifest.Graphs {
+		if g.ID == "" {
+			return errors.New("graph id is empty")
+		}
+		if graphs[g.ID] {
+			return errors.Errorf("duplicate graph: %s", g.ID)
+		}
+		graphs[g.ID] = true
+	}
+
+	webhooks := make(map[string]bool, len(manifest.Webhooks))
+	for _, w := range manifest.Webhooks {
+		if graphs[w.GraphID] == false {
+			return errors.Errorf("webhook refers to undefined graph: %s", w.GraphID)
+		}
+		key := w.GraphID + " " + w.Type
+		if webhooks[key] {
+			return errors.Errorf("duplicate webhook: %s %s", w.GraphID, w.Type)
+		}
+		webhooks[key] = true
+	}
+	return nil
+}
+
+// normalizeGraphDefinition fills the fields Pixela defaults when they are not specified.
+func normalizeGraphDefinition(d GraphDefinition) GraphDefinition {
+	if d.TimeZone == "" {
+		d.TimeZone = "UTC"
+	}
+	if d.SelfSufficient == "" {
+		d.SelfSufficient = SelfSufficientNone
+	}
+	return d
+}
+
+func diffGraphDefinition(have, want GraphDefinition) []string {
+	var fields []string
+	if have.Name != want.Name {
+		fields = append(fields, "name")
+	}
+	if have.Unit != want.Unit {
+		fields = append(fields, "unit")
+	}
+	if have.Color != want.Color {
+		fields = append(fields, "color")
+	}
+	if have.TimeZone != want.TimeZone {
+		fields = append(fields, "timezone")
+	}
+	if (len(have.PurgeCacheURLs) != 0 || len(want.PurgeCacheURLs) != 0) && reflect.DeepEqual(have.PurgeCacheURLs, want.PurgeCacheURLs) == false {
+		fields = append(fields, "purgeCacheURLs")
+	}
+	if have.SelfSufficient != want.SelfSufficient {
+		fields = append(fields, "selfSufficient")
+	}
+	if have.IsSecret != want.IsSecret {
+		fields = append(fields, "isSecret")
+	}
+	if have.PublishOptionalData != want.PublishOptionalData {
+		fields = append(fields, "publishOptionalData")
+	}
+	return fields
+}
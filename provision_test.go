@@ -0,0 +1,172 @@
+package pixela
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newProvisionMock(requests *[]string) *httpClientMock {
+	return &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		*requests = append(*requests, req.Method+" "+req.URL.Path)
+		if req.Method != http.MethodGet {
+			return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+		}
+		if strings.HasSuffix(req.URL.Path, "/webhooks") {
+			return http.StatusOK, []byte(`{"webhooks":[{"webhookHash":"hash","graphId":"old-graph","type":"increment"}]}`)
+		}
+		return http.StatusOK, []byte(`{"graphs":[` +
+			`{"id":"graph-id","name":"graph-name","unit":"commit","type":"int","color":"shibafu","timezone":"UTC","purgeCacheURLs":[],"selfSufficient":"none","isSecret":false,"publishOptionalData":false},` +
+			`{"id":"old-graph","name":"old","unit":"commit","type":"int","color":"shibafu","timezone":"UTC","purgeCacheURLs":[],"selfSufficient":"none","isSecret":false,"publishOptionalData":false}]}`)
+	}}
+}
+
+const testManifest = `{
+  "graphs": [
+    {"id": "graph-id", "name": "graph-name", "unit": "commit", "type": "int", "color": "momiji"},
+    {"id": "new-graph", "name": "new", "unit": "times", "type": "float", "color": "sora", "timezone": "Asia/Tokyo"}
+  ],
+  "webhooks": [
+    {"graphId": "new-graph", "type": "increment"}
+  ]
+}`
+
+const testManifestYAML = `# The graphs and webhooks of the user.
+graphs:
+- id: graph-id
+  name: graph-name
+  unit: commit
+  type: int
+  color: momiji
+- {id: new-graph, name: new, unit: times, type: float, color: sora, timezone: "Asia/Tokyo"}
+webhooks:
+  - graphId: new-graph
+    type: increment
+`
+
+func TestReadManifestYAML(t *testing.T) {
+	actual, err := ReadManifest(strings.NewReader(testManifestYAML))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	expect, err := ReadManifest(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(actual, expect) == false {
+		t.Errorf("got: %+v\nwant: %+v", actual, expect)
+	}
+}
+
+func TestReadManifestUnknownField(t *testing.T) {
+	for _, s := range []string{`{"graphs":[{"id":"graph-id","colour":"momiji"}]}`, "graphs:\n- id: graph-id\n  colour: momiji\n"} {
+		if _, err := ReadManifest(strings.NewReader(s)); err == nil {
+			t.Errorf("%s: got: nil\nwant: error", s)
+		}
+	}
+}
+
+func TestClientPlan(t *testing.T) {
+	var requests []string
+	clientMock = newProvisionMock(&requests)
+
+	manifest, err := ReadManifest(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	client := Client{UserName: userName, Token: token}
+	plan, err := client.Plan(manifest, &PlanOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := `~ graph graph-id (color)
++ graph new-graph
++ webhook new-graph increment
+- webhook old-graph increment
+- graph old-graph
+Plan: 2 to create, 1 to update, 2 to delete.
+`
+	if plan.String() != expect {
+		t.Errorf("got: %s\nwant: %s", plan.String(), expect)
+	}
+}
+
+func TestClientPlanWithoutPrune(t *testing.T) {
+	var requests []string
+	clientMock = newProvisionMock(&requests)
+
+	manifest, err := ReadManifest(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	client := Client{UserName: userName, Token: token}
+	plan, err := client.Plan(manifest, nil)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := `~ graph graph-id (color)
++ graph new-graph
++ webhook new-graph increment
+Plan: 2 to create, 1 to update, 0 to delete.
+`
+	if plan.String() != expect {
+		t.Errorf("got: %s\nwant: %s", plan.String(), expect)
+	}
+}
+
+func TestClientPlanTypeChanged(t *testing.T) {
+	var requests []string
+	clientMock = newProvisionMock(&requests)
+
+	manifest := &Manifest{Graphs: []GraphDefinition{{ID: graphID, Type: TypeFloat}}}
+	client := Client{UserName: userName, Token: token}
+	if _, err := client.Plan(manifest, nil); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestClientPlanUndefinedGraph(t *testing.T) {
+	manifest := &Manifest{Webhooks: []WebhookDefinition{{GraphID: graphID, Type: SelfSufficientIncrement}}}
+	client := Client{UserName: userName, Token: token}
+	if _, err := client.Plan(manifest, nil); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestClientApply(t *testing.T) {
+	var requests []string
+	clientMock = newProvisionMock(&requests)
+
+	manifest, err := ReadManifest(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	client := Client{UserName: userName, Token: token}
+	plan, err := client.Plan(manifest, &PlanOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	requests = nil
+	if err := client.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	base := "/v1/users/" + userName
+	expect := []string{
+		http.MethodPut + " " + base + "/graphs/graph-id",
+		http.MethodPost + " " + base + "/graphs",
+		http.MethodPost + " " + base + "/webhooks",
+		http.MethodDelete + " " + base + "/webhooks/hash",
+		http.MethodDelete + " " + base + "/graphs/old-graph",
+	}
+	if reflect.DeepEqual(requests, expect) == false {
+		t.Errorf("got: %v\nwant: %v", requests, expect)
+	}
+}
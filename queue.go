@@ -0,0 +1,519 @@
+package pixela
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrQueued is returned by Queue when the operation could not be sent and is queued to be replayed.
+var ErrQueued = errors.New("operation is queued")
+
+// It is the kind of QueuedOperation.
+const (
+	OperationCreate    = "create"
+	OperationUpdate    = "update"
+	OperationDelete    = "delete"
+	OperationIncrement = "increment"
+	OperationInvoke    = "invoke"
+)
+
+// QueuedOperation is a pixel or webhook operation waiting to be replayed.
+type QueuedOperation struct {
+	ID           int64  `json:"id"`
+	Kind         string `json:"kind"`
+	GraphID      string `json:"graphId,omitempty"`
+	Date         string `json:"date"`
+	Quantity     string `json:"quantity,omitempty"`
+	OptionalData string `json:"optionalData,omitempty"`
+	WebhookHash  string `json:"webhookHash,omitempty"`
+	// Steps is the number of increments (negative for decrements) or webhook invocations.
+	Steps int `json:"steps,omitempty"`
+	// Target is the quantity an increment is replayed as.
+	// It is fixed before the increment is sent, so that replaying it again is idempotent.
+	Target string `json:"target,omitempty"`
+}
+
+func (op *QueuedOperation) key() string {
+	return op.GraphID + "/" + op.Date
+}
+
+// It is the kind of the record in the queue file.
+const (
+	queueRecordOperation = "op"
+	queueRecordTarget    = "target"
+	queueRecordAck       = "ack"
+)
+
+type queueRecord struct {
+	Type      string           `json:"type"`
+	ID        int64            `json:"id,omitempty"`
+	Operation *QueuedOperation `json:"op,omitempty"`
+	GraphID   string           `json:"graphId,omitempty"`
+	Target    string           `json:"target,omitempty"`
+}
+
+// Queue sends pixel and webhook operations, and records them in a local append-only file when they can not be sent.
+// The recorded operations are replayed in order by Replay.
+//
+// Increments, decrements and webhook invocations are applied to "today" by Pixela,
+// so the queued ones are fixed to the date they were queued and replayed as updates of that date.
+type Queue struct {
+	client *Client
+	path   string
+	// Location is the time zone to decide the date of queued increments, decrements and webhook invocations.
+	// If nil, time.Local is used.
+	Location *time.Location
+
+	mu         sync.Mutex
+	operations []*QueuedOperation
+	nextID     int64
+	now        func() time.Time
+}
+
+// OpenQueue opens the queue file at path, creating it if it does not exist.
+// A broken last line, left by a crash while appending it, is dropped;
+// any other broken line is an error because the records after it would be lost.
+func OpenQueue(client *Client, path string) (*Queue, error) {
+	q := &Queue{client: client, path: path, nextID: 1, now: time.Now}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil && os.IsNotExist(err) == false {
+		return nil, errors.Wrap(err, "failed to read queue file")
+	}
+
+	index := map[int64]*QueuedOperation{}
+	broken := len(b) > 0 && b[len(b)-1] != '\n'
+	brokenLine := 0
+	line := 0
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if brokenLine > 0 {
+			return nil, errors.Errorf("failed to parse queue file: line %d is broken", brokenLine)
+		}
+		var record queueRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// The last line may be broken by a crash while appending it.
+			broken = true
+			brokenLine = line
+			continue
+		}
+
+		switch record.Type {
+		case queueRecordOperation:
+			if record.Operation == nil {
+				continue
+			}
+			q.operations = append(q.operations, record.Operation)
+			index[record.Operation.ID] = record.Operation
+			if record.Operation.ID >= q.nextID {
+				q.nextID = record.Operation.ID + 1
+			}
+		case queueRecordTarget:
+			if op, ok := index[record.ID]; ok {
+				op.GraphID = record.GraphID
+				op.Target = record.Target
+			}
+		case queueRecordAck:
+			q.remove(record.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read queue file")
+	}
+
+	// Drop the broken tail, or the next record would be appended to it and lost too.
+	if broken {
+		if err := q.rewrite(); err != nil {
+			return nil, errors.Wrap(err, "failed to repair queue file")
+		}
+	}
+	return q, nil
+}
+
+// Pending returns the operations waiting to be replayed.
+func (q *Queue) Pending() []QueuedOperation {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := make([]QueuedOperation, len(q.operations))
+	for i, op := range q.operations {
+		pending[i] = *op
+	}
+	return pending
+}
+
+func (q *Queue) today() string {
+	loc := q.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return q.now().In(loc).Format(dateFormat)
+}
+
+// Create records the quantity of the specified date as a "Pixel", or queues it if it can not be sent.
+func (q *Queue) Create(graphID, date, quantity, optionalData string) (*Result, error) {
+	return q.send(&QueuedOperation{Kind: OperationCreate, GraphID: graphID, Date: date, Quantity: quantity, OptionalData: optionalData})
+}
+
+// Update updates the quantity already registered as a "Pixel", or queues it if it can not be sent.
+func (q *Queue) Update(graphID, date, quantity, optionalData string) (*Result, error) {
+	return q.send(&QueuedOperation{Kind: OperationUpdate, GraphID: graphID, Date: date, Quantity: quantity, OptionalData: optionalData})
+}
+
+// Delete deletes the registered "Pixel", or queues it if it can not be sent.
+func (q *Queue) Delete(graphID, date string) (*Result, error) {
+	return q.send(&QueuedOperation{Kind: OperationDelete, GraphID: graphID, Date: date})
+}
+
+// Increment increments quantity "Pixel" of the day, or queues it if it can not be sent.
+func (q *Queue) Increment(graphID string) (*Result, error) {
+	return q.send(&QueuedOperation{Kind: OperationIncrement, GraphID: graphID, Date: q.today(), Steps: 1})
+}
+
+// Decrement decrements quantity "Pixel" of the day, or queues it if it can not be sent.
+func (q *Queue) Decrement(graphID string) (*Result, error) {
+	return q.send(&QueuedOperation{Kind: OperationIncrement, GraphID: graphID, Date: q.today(), Steps: -1})
+}
+
+// Invoke invokes the webhook registered in advance, or queues it if it can not be sent.
+func (q *Queue) Invoke(webhookHash string) (*Result, error) {
+	return q.send(&QueuedOperation{Kind: OperationInvoke, WebhookHash: webhookHash, Date: q.today(), Steps: 1})
+}
+
+// send sends the operation directly if nothing is queued, and queues it if it is not sent
+// or is rejected with a retryable error such as 503, as Replay keeps such operations.
+// An operation is queued behind the pending ones to keep the order.
+func (q *Queue) send(op *QueuedOperation) (*Result, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.operations) == 0 {
+		result, err := q.sendDirect(op)
+		if err == nil && (result.IsSuccess || isDefinitive(result)) {
+			return result, nil
+		}
+		if _, ok := err.(ValidationErrors); ok {
+			return result, err
+		}
+	}
+
+	op.ID = q.nextID
+	q.nextID++
+	if err := q.append(&queueRecord{Type: queueRecordOperation, Operation: op}); err != nil {
+		return &Result{}, errors.Wrap(err, "failed to queue operation")
+	}
+	q.operations = append(q.operations, op)
+	return &Result{}, ErrQueued
+}
+
+func (q *Queue) sendDirect(op *QueuedOperation) (*Result, error) {
+	pixel := q.client.Pixel(op.GraphID)
+	switch op.Kind {
+	case OperationCreate:
+		return pixel.Create(op.Date, op.Quantity, op.OptionalData)
+	case OperationUpdate:
+		return pixel.Update(op.Date, op.Quantity, op.OptionalData)
+	case OperationDelete:
+		return pixel.Delete(op.Date)
+	case OperationIncrement:
+		if op.Steps < 0 {
+			return pixel.Decrement()
+		}
+		return pixel.Increment()
+	case OperationInvoke:
+		return q.client.Webhook().Invoke(op.WebhookHash)
+	}
+	return &Result{}, errors.Errorf("unsupported operation: %s", op.Kind)
+}
+
+// ReplayResult is the result of Replay.
+type ReplayResult struct {
+	// Sent is the operations accepted by Pixela.
+	Sent []QueuedOperation
+	// Failed is the operations rejected by Pixela with a client error such as 400 or 404.
+	// They are removed from the queue.
+	Failed []QueuedOperation
+}
+
+// Replay merges redundant operations and sends the queued operations in order.
+// It stops at the first operation that can not be sent or is rejected with a retryable error such as 503,
+// which stays in the queue with the following ones.
+func (q *Queue) Replay(ctx context.Context) (*ReplayResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.operations = mergeOperations(q.operations)
+	if err := q.rewrite(); err != nil {
+		return nil, err
+	}
+
+	r := &replayer{queue: q, types: map[string]string{}}
+	result := &ReplayResult{}
+	for len(q.operations) > 0 {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		op := q.operations[0]
+		sent, err := r.replay(op)
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to replay operation %d", op.ID)
+		}
+		if sent.IsSuccess == false && isDefinitive(sent) == false {
+			return result, errors.Errorf("failed to replay operation %d: %s", op.ID, sent.Message)
+		}
+		if err := q.append(&queueRecord{Type: queueRecordAck, ID: op.ID}); err != nil {
+			return result, err
+		}
+		q.operations = q.operations[1:]
+		if sent.IsSuccess {
+			result.Sent = append(result.Sent, *op)
+		} else {
+			result.Failed = append(result.Failed, *op)
+		}
+	}
+
+	return result, q.rewrite()
+}
+
+type replayer struct {
+	queue    *Queue
+	types    map[string]string
+	webhooks []WebhookDefinition
+}
+
+func (r *replayer) replay(op *QueuedOperation) (*Result, error) {
+	// The operation is changed only together with the target record, so that a failed replay can be retried as is.
+	graphID, steps := op.GraphID, op.Steps
+	if op.Kind == OperationInvoke && op.Target == "" {
+		webhook, err := r.webhook(op.WebhookHash)
+		if err != nil {
+			return nil, err
+		}
+		if webhook == nil {
+			for i := 0; i < op.Steps; i++ {
+				result, err := r.queue.client.Webhook().Invoke(op.WebhookHash)
+				if err != nil || result.IsSuccess == false {
+					return result, err
+				}
+			}
+			return &Result{IsSuccess: true}, nil
+		}
+
+		graphID = webhook.GraphID
+		if webhook.Type == SelfSufficientDecrement {
+			steps = -steps
+		}
+	}
+	if op.Kind != OperationIncrement && op.Kind != OperationInvoke {
+		return r.queue.sendDirect(op)
+	}
+
+	pixel := r.queue.client.pixel(graphID)
+	// The cache is bypassed so that the increments of the other processes are not lost.
+	current, err := pixel.get(op.Date, false)
+	if err != nil {
+		return nil, err
+	}
+	if current.IsSuccess == false && current.StatusCode != http.StatusNotFound {
+		return &current.Result, nil
+	}
+	if op.Target == "" {
+		target, err := r.target(graphID, steps, current)
+		if err != nil {
+			return nil, err
+		}
+		record := &queueRecord{Type: queueRecordTarget, ID: op.ID, GraphID: graphID, Target: target}
+		if err := r.queue.append(record); err != nil {
+			return nil, err
+		}
+		op.GraphID = graphID
+		op.Target = target
+	}
+
+	if current.IsSuccess {
+		return pixel.Update(op.Date, op.Target, current.OptionalData)
+	}
+	return pixel.Create(op.Date, op.Target, "")
+}
+
+// target returns the quantity of the pixel after the increments.
+func (r *replayer) target(graphID string, steps int, current *Quantity) (string, error) {
+	quantityType, ok := r.types[graphID]
+	if ok == false {
+		definition, err := r.queue.client.graph(graphID).definition()
+		if err != nil {
+			return "", err
+		}
+		quantityType = definition.Type
+		r.types[graphID] = quantityType
+	}
+
+	quantity := "0"
+	if current.IsSuccess {
+		quantity = current.Quantity
+	}
+	if quantityType == TypeInt {
+		n, err := strconv.Atoi(quantity)
+		if err != nil {
+			return "", errors.Wrapf(err, "quantity is not int: %s", quantity)
+		}
+		return strconv.Itoa(n + steps), nil
+	}
+	f, err := strconv.ParseFloat(quantity, 64)
+	if err != nil {
+		return "", errors.Wrapf(err, "quantity is not float: %s", quantity)
+	}
+	f = math.Round((f+float64(steps)*0.01)*1e9) / 1e9
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+// isDefinitive reports whether the failed result is a client error that will fail again if the operation is retried.
+// Server errors such as "503 Please retry" and 429 are retryable.
+func isDefinitive(result *Result) bool {
+	return result.StatusCode >= 400 && result.StatusCode < 500 && result.StatusCode != http.StatusTooManyRequests
+}
+
+func (r *replayer) webhook(hash string) (*WebhookDefinition, error) {
+	if r.webhooks == nil {
+		definitions, err := r.queue.client.Webhook().GetAll()
+		if err := checkResult(&definitions.Result, err); err != nil {
+			return nil, errors.Wrap(err, "failed to get all webhooks definitions")
+		}
+		r.webhooks = append([]WebhookDefinition{}, definitions.Webhooks...)
+	}
+	for i := range r.webhooks {
+		if r.webhooks[i].WebhookHash == hash {
+			return &r.webhooks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// mergeOperations removes the operations superseded by later ones and merges consecutive increments and invocations.
+// A create, update or delete of a pixel supersedes all earlier operations of the same pixel.
+func mergeOperations(operations []*QueuedOperation) []*QueuedOperation {
+	var merged []*QueuedOperation
+	for _, op := range operations {
+		if op.Target != "" {
+			merged = append(merged, op)
+			continue
+		}
+
+		switch op.Kind {
+		case OperationCreate, OperationUpdate, OperationDelete:
+			kept := merged[:0]
+			for _, m := range merged {
+				if m.Target != "" || m.Kind == OperationInvoke || m.key() != op.key() {
+					kept = append(kept, m)
+				}
+			}
+			merged = kept
+		case OperationIncrement, OperationInvoke:
+			if last := lastMergeable(merged, op); last != nil {
+				last.Steps += op.Steps
+				continue
+			}
+		}
+		merged = append(merged, op)
+	}
+
+	kept := merged[:0]
+	for _, m := range merged {
+		if m.Kind == OperationIncrement && m.Steps == 0 && m.Target == "" {
+			continue
+		}
+		kept = append(kept, m)
+	}
+	return kept
+}
+
+// lastMergeable returns the last operation the increment or invocation can be merged into.
+// It is the last operation of the same pixel or webhook, if no operation of the pixel is between them.
+func lastMergeable(merged []*QueuedOperation, op *QueuedOperation) *QueuedOperation {
+	for i := len(merged) - 1; i >= 0; i-- {
+		m := merged[i]
+		if op.Kind == OperationInvoke {
+			if m.Kind == OperationInvoke && m.WebhookHash == op.WebhookHash {
+				if m.Date == op.Date && m.Target == "" {
+					return m
+				}
+				return nil
+			}
+			// The graph of the webhook is unknown, so it can not be merged across pixel operations.
+			if m.Kind != OperationInvoke {
+				return nil
+			}
+			continue
+		}
+
+		if m.Kind == OperationInvoke || m.key() != op.key() {
+			continue
+		}
+		if m.Kind == OperationIncrement && m.Target == "" {
+			return m
+		}
+		return nil
+	}
+	return nil
+}
+
+func (q *Queue) append(record *queueRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal json")
+	}
+
+	f, err := os.OpenFile(q.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open queue file")
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to write queue file")
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to sync queue file")
+	}
+	return errors.Wrap(f.Close(), "failed to close queue file")
+}
+
+// rewrite replaces the queue file with the pending operations.
+func (q *Queue) rewrite() error {
+	var buf bytes.Buffer
+	for _, op := range q.operations {
+		b, err := json.Marshal(&queueRecord{Type: queueRecordOperation, Operation: op})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal json")
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	return writeFileAtomic(q.path, buf.Bytes(), 0600)
+}
+
+func (q *Queue) remove(id int64) {
+	for i, op := range q.operations {
+		if op.ID == id {
+			q.operations = append(q.operations[:i], q.operations[i+1:]...)
+			return
+		}
+	}
+}
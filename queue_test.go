@@ -0,0 +1,314 @@
+package pixela
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newOfflineMock() *httpClientMock {
+	return &httpClientMock{err: errors.New("network is unreachable")}
+}
+
+// newReplayMock returns a mock of a graph of int type that has the pixel of 20180915 with quantity 5.
+func newReplayMock(requests *[]string) *httpClientMock {
+	return &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		var body []byte
+		if req.Body != nil {
+			body, _ = ioutil.ReadAll(req.Body)
+		}
+		*requests = append(*requests, req.Method+" "+req.URL.Path+" "+string(body))
+
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/users/"+userName+"/graphs":
+			return http.StatusOK, []byte(testGraphDefinitions)
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/users/"+userName+"/webhooks":
+			return http.StatusOK, []byte(`{"webhooks":[{"webhookHash":"hash","graphId":"graph-id","type":"decrement"}]}`)
+		case req.Method == http.MethodGet:
+			return http.StatusOK, []byte(`{"quantity":"5","optionalData":""}`)
+		}
+		return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+	}}
+}
+
+func openTestQueue(t *testing.T, path string) *Queue {
+	q, err := OpenQueue(NewClient(userName, token), path)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	q.Location = time.UTC
+	q.now = func() time.Time { return time.Date(2018, 9, 15, 12, 0, 0, 0, time.UTC) }
+	return q
+}
+
+func TestQueueReplay(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	clientMock = newOfflineMock()
+	q := openTestQueue(t, path)
+	for _, f := range []func(string) (*Result, error){q.Increment, q.Increment, q.Increment, q.Decrement} {
+		if _, err := f(graphID); err != ErrQueued {
+			t.Fatalf("got: %v\nwant: %v", err, ErrQueued)
+		}
+	}
+
+	q = openTestQueue(t, path)
+	if len(q.Pending()) != 4 {
+		t.Fatalf("pending: %v\nwant: 4 operations", q.Pending())
+	}
+
+	var requests []string
+	clientMock = newReplayMock(&requests)
+	result, err := q.Replay(context.Background())
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	if len(result.Sent) != 1 || len(q.Pending()) != 0 {
+		t.Errorf("sent: %v, pending: %v\nwant: 1 sent, 0 pending", result.Sent, q.Pending())
+	}
+
+	last := requests[len(requests)-1]
+	expect := "PUT /v1/users/user/graphs/graph-id/20180915 " + `{"quantity":"7","optionalData":""}`
+	if last != expect {
+		t.Errorf("got: %s\nwant: %s", last, expect)
+	}
+
+	q = openTestQueue(t, path)
+	if len(q.Pending()) != 0 {
+		t.Errorf("pending after reopen: %v\nwant: none", q.Pending())
+	}
+}
+
+func TestQueueReplayInvoke(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clientMock = newOfflineMock()
+	q := openTestQueue(t, filepath.Join(dir, "queue"))
+	q.Invoke("hash")
+	q.Invoke("hash")
+
+	var requests []string
+	clientMock = newReplayMock(&requests)
+	if _, err := q.Replay(context.Background()); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	last := requests[len(requests)-1]
+	expect := "PUT /v1/users/user/graphs/graph-id/20180915 " + `{"quantity":"3","optionalData":""}`
+	if last != expect {
+		t.Errorf("got: %s\nwant: %s", last, expect)
+	}
+}
+
+func TestQueueReplayIsIdempotent(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	// The process crashed after the target of the increment was recorded.
+	s := `{"type":"op","op":{"id":1,"kind":"increment","graphId":"graph-id","date":"20180914","steps":2}}
+{"type":"target","id":1,"graphId":"graph-id","target":"4"}
+`
+	if err := ioutil.WriteFile(path, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests []string
+	clientMock = newReplayMock(&requests)
+	q := openTestQueue(t, path)
+	if _, err := q.Replay(context.Background()); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := []string{
+		"GET /v1/users/user/graphs/graph-id/20180914 ",
+		"PUT /v1/users/user/graphs/graph-id/20180914 " + `{"quantity":"4","optionalData":""}`,
+	}
+	if reflect.DeepEqual(requests, expect) == false {
+		t.Errorf("got: %v\nwant: %v", requests, expect)
+	}
+}
+
+func TestOpenQueueRepairsBrokenTail(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	// The process crashed while appending the second operation.
+	s := `{"type":"op","op":{"id":1,"kind":"delete","graphId":"graph-id","date":"20180914"}}
+{"type":"op","op":{"id":2,"kind":"del`
+	if err := ioutil.WriteFile(path, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	clientMock = newOfflineMock()
+	q := openTestQueue(t, path)
+	q.Delete(graphID, "20180915")
+	q.Delete(graphID, "20180916")
+
+	var dates []string
+	for _, op := range openTestQueue(t, path).Pending() {
+		dates = append(dates, op.Date)
+	}
+	expect := []string{"20180914", "20180915", "20180916"}
+	if reflect.DeepEqual(dates, expect) == false {
+		t.Errorf("got: %v\nwant: %v", dates, expect)
+	}
+}
+
+func TestOpenQueueBrokenMiddle(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	s := `{"type":"op","op":{"id":1,"kind":"delete","graphId":"graph-id","date":"20180914"}}
+{"type":"op","op":{"id":2,"kind":"del
+{"type":"op","op":{"id":3,"kind":"delete","graphId":"graph-id","date":"20180916"}}
+`
+	if err := ioutil.WriteFile(path, []byte(s), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenQueue(NewClient(userName, token), path); err == nil {
+		t.Errorf("got: nil\nwant: the error of the broken line")
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil || string(b) != s {
+		t.Errorf("got: %s, %v\nwant: the queue file unchanged", b, err)
+	}
+}
+
+func TestQueueReplayKeepsRetryableFailure(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	clientMock = newOfflineMock()
+	q := openTestQueue(t, path)
+	q.Delete(graphID, "20180915")
+
+	clientMock = &httpClientMock{
+		statusCode: http.StatusServiceUnavailable,
+		body:       []byte(`{"message":"Please retry this request.","isSuccess":false}`),
+	}
+	result, err := q.Replay(context.Background())
+	if err == nil {
+		t.Errorf("got: nil\nwant: the retryable failure")
+	}
+	if len(result.Failed) != 0 || len(openTestQueue(t, path).Pending()) != 1 {
+		t.Errorf("failed: %v, pending: %v\nwant: the operation pending", result.Failed, q.Pending())
+	}
+
+	clientMock = newAPIFailedMock()
+	result, err = q.Replay(context.Background())
+	if err != nil {
+		t.Errorf("got: %v\nwant: nil", err)
+	}
+	if len(result.Failed) != 1 || len(openTestQueue(t, path).Pending()) != 0 {
+		t.Errorf("failed: %v, pending: %v\nwant: the operation removed", result.Failed, q.Pending())
+	}
+}
+
+func TestQueueReplayFailureKeepsOperation(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clientMock = newOfflineMock()
+	q := openTestQueue(t, filepath.Join(dir, "queue"))
+	q.Invoke("hash")
+
+	var requests []string
+	mock := newReplayMock(&requests)
+	clientMock = &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		if req.URL.Path == "/v1/users/"+userName+"/graphs/"+graphID+"/20180915" {
+			return http.StatusServiceUnavailable, []byte(`{"message":"Please retry this request.","isSuccess":false}`)
+		}
+		return mock.handler(req)
+	}}
+	q.Replay(context.Background())
+
+	pending := q.Pending()
+	if len(pending) != 1 || pending[0].Steps != 1 || pending[0].GraphID != "" || pending[0].Target != "" {
+		t.Errorf("got: %+v\nwant: the invocation unchanged", pending)
+	}
+}
+
+func TestQueueSendOnline(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clientMock = newOKMock()
+	q := openTestQueue(t, filepath.Join(dir, "queue"))
+	result, err := q.Create(graphID, "20180915", "5", "")
+	testSuccess(t, result, err)
+	if len(q.Pending()) != 0 {
+		t.Errorf("pending: %v\nwant: none", q.Pending())
+	}
+}
+
+func TestQueueSendRetryableFailure(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clientMock = &httpClientMock{
+		statusCode: http.StatusServiceUnavailable,
+		body:       []byte(`{"message":"Please retry this request.","isSuccess":false}`),
+	}
+	q := openTestQueue(t, filepath.Join(dir, "queue"))
+	if _, err := q.Increment(graphID); err != ErrQueued {
+		t.Errorf("got: %v\nwant: %v", err, ErrQueued)
+	}
+	if len(openTestQueue(t, filepath.Join(dir, "queue")).Pending()) != 1 {
+		t.Errorf("pending: %v\nwant: the increment", q.Pending())
+	}
+}
+
+func TestQueueSendClientError(t *testing.T) {
+	dir := newTempDir(t)
+	defer os.RemoveAll(dir)
+
+	clientMock = newAPIFailedMock()
+	q := openTestQueue(t, filepath.Join(dir, "queue"))
+	result, err := q.Delete(graphID, "20180916")
+	testAPIFailedResult(t, result, err)
+	if len(q.Pending()) != 0 {
+		t.Errorf("pending: %v\nwant: none", q.Pending())
+	}
+}
+
+func TestMergeOperations(t *testing.T) {
+	operations := []*QueuedOperation{
+		{ID: 1, Kind: OperationIncrement, GraphID: graphID, Date: "20180915", Steps: 1},
+		{ID: 2, Kind: OperationIncrement, GraphID: "other", Date: "20180915", Steps: 1},
+		{ID: 3, Kind: OperationIncrement, GraphID: graphID, Date: "20180915", Steps: 1},
+		{ID: 4, Kind: OperationUpdate, GraphID: "other", Date: "20180915", Quantity: "10"},
+		{ID: 5, Kind: OperationIncrement, GraphID: "other", Date: "20180915", Steps: 1},
+		{ID: 6, Kind: OperationInvoke, WebhookHash: "hash", Date: "20180915", Steps: 1},
+		{ID: 7, Kind: OperationInvoke, WebhookHash: "hash", Date: "20180915", Steps: 1},
+		{ID: 8, Kind: OperationIncrement, GraphID: graphID, Date: "20180916", Steps: 1},
+		{ID: 9, Kind: OperationIncrement, GraphID: graphID, Date: "20180916", Steps: -1},
+	}
+
+	actual := mergeOperations(operations)
+	expect := []*QueuedOperation{
+		{ID: 1, Kind: OperationIncrement, GraphID: graphID, Date: "20180915", Steps: 2},
+		{ID: 4, Kind: OperationUpdate, GraphID: "other", Date: "20180915", Quantity: "10"},
+		{ID: 5, Kind: OperationIncrement, GraphID: "other", Date: "20180915", Steps: 1},
+		{ID: 6, Kind: OperationInvoke, WebhookHash: "hash", Date: "20180915", Steps: 2},
+	}
+	if reflect.DeepEqual(actual, expect) == false {
+		for _, op := range actual {
+			t.Logf("%v", *op)
+		}
+		t.Errorf("got: %v\nwant: %v", actual, expect)
+	}
+}
@@ -0,0 +1,73 @@
+package pixela
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the secrets masked by Redact.
+const Redacted = "[REDACTED]"
+
+var (
+	secretFieldPattern = regexp.MustCompile(`("(?:token|newToken|webhookHash)"\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+	webhookPathPattern = regexp.MustCompile(`(/webhooks/)([^/?#\s"]+)`)
+)
+
+// Redact masks the secrets in s: the values of the token, newToken and webhookHash JSON fields,
+// the webhook hashes in webhook URLs, and the given secrets such as the token of the user.
+func Redact(s string, secrets ...string) string {
+	s = secretFieldPattern.ReplaceAllString(s, `${1}"`+Redacted+`"`)
+	s = webhookPathPattern.ReplaceAllString(s, "${1}"+Redacted)
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.Replace(s, secret, Redacted, -1)
+		}
+	}
+	return s
+}
+
+// secrets returns the token, the webhook hash and the tokens in the body of the request.
+func (param *requestParameter) secrets() []string {
+	secrets := []string{param.Header[userToken]}
+	if m := webhookPathPattern.FindStringSubmatch(param.URL); m != nil {
+		secrets = append(secrets, m[2])
+	}
+	for _, m := range secretFieldPattern.FindAllSubmatch(param.Body, -1) {
+		secrets = append(secrets, string(m[2]))
+	}
+	return secrets
+}
+
+// redact masks the secrets of the request in s.
+func (param *requestParameter) redact(s string) string {
+	return Redact(s, param.secrets()...)
+}
+
+// redactError masks the secrets of the request in the URL of a *url.Error,
+// which net/http returns with the URL of the request.
+func (param *requestParameter) redactError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		urlErr.URL = param.redact(urlErr.URL)
+	}
+	return err
+}
+
+// redacted returns a copy of the request with its secrets masked, to be shown in debug output.
+func (param *requestParameter) redacted() *requestParameter {
+	secrets := param.secrets()
+	header := make(map[string]string, len(param.Header))
+	for k, v := range param.Header {
+		if k == userToken {
+			v = Redacted
+		}
+		header[k] = v
+	}
+	return &requestParameter{
+		Operation: param.Operation,
+		Method:    param.Method,
+		URL:       Redact(param.URL, secrets...),
+		Header:    header,
+		Body:      []byte(Redact(string(param.Body), secrets...)),
+	}
+}
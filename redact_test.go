@@ -0,0 +1,111 @@
+package pixela
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const webhookHash = "secret-webhook-hash"
+
+func testRedacted(t *testing.T, s string) {
+	for _, secret := range []string{token, newToken, webhookHash} {
+		if strings.Contains(s, secret) {
+			t.Errorf("got: %s\nwant: %s is redacted", s, secret)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	s := `{"token":"a","newToken" : "b\"c","webhookHash":"d","name":"e"} POST /v1/users/user/webhooks/f?x=1 g`
+	expect := `{"token":"[REDACTED]","newToken" : "[REDACTED]","webhookHash":"[REDACTED]","name":"e"} POST /v1/users/user/webhooks/[REDACTED]?x=1 [REDACTED]`
+	if got := Redact(s, "g", ""); got != expect {
+		t.Errorf("got: %s\nwant: %s", got, expect)
+	}
+}
+
+func TestRequestParameterRedacted(t *testing.T) {
+	param := &requestParameter{
+		Method: http.MethodPut,
+		URL:    APIBaseURL + "/users/user/webhooks/" + webhookHash,
+		Header: map[string]string{userToken: token, contentLength: "0"},
+		Body:   []byte(`{"newToken":"` + newToken + `"}`),
+	}
+	redacted := param.redacted()
+
+	testRedacted(t, redacted.URL+string(redacted.Body))
+	if redacted.Header[userToken] != Redacted || redacted.Header[contentLength] != "0" {
+		t.Errorf("got: %v\nwant: only %s is redacted", redacted.Header, userToken)
+	}
+	if param.Header[userToken] != token {
+		t.Errorf("got: %s\nwant: the request is not changed", param.Header[userToken])
+	}
+}
+
+func TestRedactUpdateUserError(t *testing.T) {
+	clientMock = &httpClientMock{
+		statusCode: http.StatusBadRequest,
+		body:       []byte("bad request: " + token + " " + newToken),
+	}
+
+	client := NewClient(userName, token)
+	_, err := client.UpdateUser(newToken, "")
+	if err == nil {
+		t.Fatalf("got: nil\nwant: error")
+	}
+	testRedacted(t, err.Error())
+}
+
+func TestRedactWebhookError(t *testing.T) {
+	clientMock = &httpClientMock{
+		err: &url.Error{Op: "Post", URL: APIBaseURL + "/users/user/webhooks/" + webhookHash, Err: errors.New("connection refused")},
+	}
+
+	client := NewClient(userName, token)
+	_, err := client.Webhook().Invoke(webhookHash)
+	if err == nil {
+		t.Fatalf("got: nil\nwant: error")
+	}
+	testRedacted(t, err.Error())
+
+	clientMock = &httpClientMock{
+		statusCode: http.StatusOK,
+		body:       []byte(`{"webhooks":[{"webhookHash":"` + webhookHash + `"]`),
+	}
+	_, err = client.Webhook().GetAll()
+	if err == nil {
+		t.Fatalf("got: nil\nwant: error")
+	}
+	testRedacted(t, err.Error())
+}
+
+func TestRedactTransportError(t *testing.T) {
+	defer withoutClientMock()()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(r.Header.Get(userToken)))
+	}))
+	param := &requestParameter{
+		Method: http.MethodPost,
+		URL:    server.URL + "/users/user/webhooks/" + webhookHash,
+		Header: map[string]string{userToken: token},
+	}
+	client := NewClient(userName, token)
+
+	_, err := client.mustDoRequest(param)
+	if err == nil {
+		t.Fatalf("got: nil\nwant: error")
+	}
+	testRedacted(t, err.Error())
+
+	server.Close()
+	_, err = client.doRequest(param)
+	if err == nil {
+		t.Fatalf("got: nil\nwant: error")
+	}
+	testRedacted(t, err.Error())
+}
@@ -0,0 +1,347 @@
+// Package relay provides an http.Handler that relays signed events from internal tools to Pixela,
+// so that webhook hashes and tokens are not given to third-party systems.
+//
+// An event is a POST request whose body is JSON such as
+//
+//	{"event":"deploy","id":"delivery-1","quantity":"3"}
+//
+// with the headers
+//
+//	X-Relay-Timestamp: <unix seconds>
+//	X-Relay-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" with the secret>
+package relay
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/pkg/errors"
+)
+
+// Headers of a signed event.
+const (
+	HeaderTimestamp = "X-Relay-Timestamp"
+	HeaderSignature = "X-Relay-Signature"
+)
+
+const (
+	signaturePrefix     = "sha256="
+	maxBodySize         = 64 * 1024
+	defaultMaxClockSkew = 5 * time.Minute
+	defaultDedupeWindow = 10 * time.Minute
+)
+
+// Event is what a named event does.
+// If WebhookType is specified, the webhook of the graph of the type is invoked.
+// Otherwise the quantity of the payload, or Quantity if the payload has none, is added to the pixel of the day.
+type Event struct {
+	GraphID     string
+	WebhookType string
+	Quantity    string
+}
+
+// Payload is the body of an event request.
+type Payload struct {
+	Event string `json:"event"`
+	// ID identifies the delivery. Deliveries with the same ID are relayed only once.
+	ID       string `json:"id"`
+	Quantity string `json:"quantity,omitempty"`
+}
+
+// Handler relays events to Pixela.
+type Handler struct {
+	// Secret is the key of the HMAC signature. All requests are rejected if it is empty.
+	Secret []byte
+	// Events maps event names to what they do.
+	Events map[string]Event
+	// MaxClockSkew is how old or new the timestamp of a request can be. If 0, 5 minutes is used.
+	MaxClockSkew time.Duration
+	// DedupeWindow is how long delivery IDs are remembered. If 0, 10 minutes is used.
+	DedupeWindow time.Duration
+	// Rate is the number of events relayed per second on average. If 0, events are not rate limited.
+	Rate float64
+	// Burst is the number of events relayed at once. If 0, 1 is used.
+	Burst int
+
+	api pixelaAPI
+	now func() time.Time
+
+	mu         sync.Mutex
+	deliveries map[string]time.Time
+	tokens     float64
+	filled     time.Time
+	hashes     map[string]string
+}
+
+// pixelaAPI is the part of the Pixela API used by Handler.
+type pixelaAPI interface {
+	webhooks() ([]pixela.WebhookDefinition, error)
+	invoke(hash string) (*pixela.Result, error)
+	add(graphID, quantity string) (*pixela.Result, error)
+}
+
+type clientAPI struct {
+	client *pixela.Client
+}
+
+func (c *clientAPI) webhooks() ([]pixela.WebhookDefinition, error) {
+	definitions, err := c.client.Webhook().GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if definitions.IsSuccess == false {
+		return nil, errors.Errorf("failed to call API: %s", definitions.Message)
+	}
+	return definitions.Webhooks, nil
+}
+
+func (c *clientAPI) invoke(hash string) (*pixela.Result, error) {
+	return c.client.Webhook().Invoke(hash)
+}
+
+func (c *clientAPI) add(graphID, quantity string) (*pixela.Result, error) {
+	return c.client.Pixel(graphID).Add(quantity)
+}
+
+// NewHandler returns a new Handler that relays the events with the client.
+func NewHandler(client *pixela.Client, secret []byte, events map[string]Event) *Handler {
+	return &Handler{Secret: secret, Events: events, api: &clientAPI{client: client}}
+}
+
+// Sign returns the value of HeaderSignature of the body sent at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	io.WriteString(mac, timestamp+".")
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+type response struct {
+	Message   string `json:"message"`
+	IsSuccess bool   `json:"isSuccess"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+}
+
+// ServeHTTP relays the event of the request.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, &response{Message: "method not allowed"})
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil || len(body) > maxBodySize {
+		writeResponse(w, http.StatusBadRequest, &response{Message: "failed to read body"})
+		return
+	}
+	if err := h.verify(r.Header, body); err != nil {
+		writeResponse(w, http.StatusUnauthorized, &response{Message: err.Error()})
+		return
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ID == "" {
+		writeResponse(w, http.StatusBadRequest, &response{Message: "invalid payload"})
+		return
+	}
+	event, ok := h.Events[payload.Event]
+	if ok == false {
+		writeResponse(w, http.StatusNotFound, &response{Message: "unknown event"})
+		return
+	}
+	quantity := event.Quantity
+	if payload.Quantity != "" {
+		quantity = payload.Quantity
+	}
+	if event.WebhookType == "" {
+		if _, err := strconv.ParseFloat(quantity, 64); err != nil {
+			writeResponse(w, http.StatusBadRequest, &response{Message: "invalid quantity"})
+			return
+		}
+	}
+
+	if h.seen(payload.ID) {
+		writeResponse(w, http.StatusOK, &response{Message: "duplicate", IsSuccess: true, Duplicate: true})
+		return
+	}
+	if h.allow() == false {
+		h.forget(payload.ID)
+		writeResponse(w, http.StatusTooManyRequests, &response{Message: "too many requests"})
+		return
+	}
+
+	result, err := h.relay(&event, quantity)
+	if err != nil || result.IsSuccess == false {
+		// The delivery can be retried.
+		h.forget(payload.ID)
+		writeResponse(w, http.StatusBadGateway, &response{Message: "failed to relay event"})
+		return
+	}
+	writeResponse(w, http.StatusOK, &response{Message: "relayed", IsSuccess: true})
+}
+
+func writeResponse(w http.ResponseWriter, statusCode int, resp *response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) clock() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+func (h *Handler) verify(header http.Header, body []byte) error {
+	// Anyone can sign a request with an empty secret.
+	if len(h.Secret) == 0 {
+		return errors.New("secret is not set")
+	}
+
+	timestamp := header.Get(HeaderTimestamp)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid timestamp")
+	}
+	skew := h.MaxClockSkew
+	if skew <= 0 {
+		skew = defaultMaxClockSkew
+	}
+	if d := h.clock().Sub(time.Unix(seconds, 0)); d > skew || d < -skew {
+		return errors.New("timestamp out of range")
+	}
+
+	signature := header.Get(HeaderSignature)
+	if strings.HasPrefix(signature, signaturePrefix) == false ||
+		hmac.Equal([]byte(signature), []byte(Sign(h.Secret, timestamp, body))) == false {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// seen reports whether the delivery was relayed in the dedupe window, and records it otherwise.
+func (h *Handler) seen(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	window := h.DedupeWindow
+	if window <= 0 {
+		window = defaultDedupeWindow
+	}
+	now := h.clock()
+	if h.deliveries == nil {
+		h.deliveries = map[string]time.Time{}
+	}
+	for k, t := range h.deliveries {
+		if now.Sub(t) > window {
+			delete(h.deliveries, k)
+		}
+	}
+	if _, ok := h.deliveries[id]; ok {
+		return true
+	}
+	h.deliveries[id] = now
+	return false
+}
+
+func (h *Handler) forget(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.deliveries, id)
+}
+
+// allow takes a token from the token bucket.
+func (h *Handler) allow() bool {
+	if h.Rate <= 0 {
+		return true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	burst := float64(h.Burst)
+	if burst <= 0 {
+		burst = 1
+	}
+	now := h.clock()
+	if h.filled.IsZero() {
+		h.tokens = burst
+	} else {
+		h.tokens += now.Sub(h.filled).Seconds() * h.Rate
+		if h.tokens > burst {
+			h.tokens = burst
+		}
+	}
+	h.filled = now
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
+
+func (h *Handler) relay(event *Event, quantity string) (*pixela.Result, error) {
+	if event.WebhookType == "" {
+		return h.api.add(event.GraphID, quantity)
+	}
+
+	hash, err := h.webhookHash(event.GraphID, event.WebhookType)
+	if err != nil {
+		return &pixela.Result{}, err
+	}
+	result, err := h.api.invoke(hash)
+	if err != nil || result.IsSuccess == false {
+		// The webhook may have been deleted and created again with another hash,
+		// so the hash is reloaded when the delivery is retried.
+		h.forgetWebhookHash(event.GraphID, event.WebhookType, hash)
+	}
+	return result, err
+}
+
+// webhookHash returns the hash of the webhook of the graph, calling Webhook.GetAll if it is not known yet.
+func (h *Handler) webhookHash(graphID, webhookType string) (string, error) {
+	key := graphID + "/" + webhookType
+	h.mu.Lock()
+	hash, ok := h.hashes[key]
+	h.mu.Unlock()
+	if ok {
+		return hash, nil
+	}
+
+	definitions, err := h.api.webhooks()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get webhooks")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hashes = map[string]string{}
+	for _, d := range definitions {
+		h.hashes[d.GraphID+"/"+d.Type] = d.WebhookHash
+	}
+	if hash, ok := h.hashes[key]; ok {
+		return hash, nil
+	}
+	return "", errors.Errorf("webhook not found: %s %s", graphID, webhookType)
+}
+
+// forgetWebhookHash removes the hash of the webhook of the graph unless it has been reloaded since.
+func (h *Handler) forgetWebhookHash(graphID, webhookType, hash string) {
+	key := graphID + "/" + webhookType
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.hashes[key] == hash {
+		delete(h.hashes, key)
+	}
+}
@@ -0,0 +1,206 @@
+package relay
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+)
+
+var secret = []byte("relay-secret")
+
+type apiMock struct {
+	calls []string
+	err   error
+	// hash is the hash of the webhook. If empty, "hash" is used.
+	hash string
+}
+
+func (m *apiMock) webhookHash() string {
+	if m.hash == "" {
+		return "hash"
+	}
+	return m.hash
+}
+
+func (m *apiMock) webhooks() ([]pixela.WebhookDefinition, error) {
+	m.calls = append(m.calls, "webhooks")
+	return []pixela.WebhookDefinition{{WebhookHash: m.webhookHash(), GraphID: "graph-id", Type: pixela.SelfSufficientIncrement}}, nil
+}
+
+func (m *apiMock) invoke(hash string) (*pixela.Result, error) {
+	m.calls = append(m.calls, "invoke "+hash)
+	if hash != m.webhookHash() {
+		return &pixela.Result{Message: "Specified webhook is not found.", StatusCode: http.StatusNotFound}, nil
+	}
+	return &pixela.Result{Message: "Success.", IsSuccess: m.err == nil}, m.err
+}
+
+func (m *apiMock) add(graphID, quantity string) (*pixela.Result, error) {
+	m.calls = append(m.calls, "add "+graphID+" "+quantity)
+	return &pixela.Result{Message: "Success.", IsSuccess: m.err == nil}, m.err
+}
+
+var testNow = time.Date(2018, 9, 15, 12, 0, 0, 0, time.UTC)
+
+func newTestHandler(api *apiMock) *Handler {
+	return &Handler{
+		Secret: secret,
+		Events: map[string]Event{
+			"push":   {GraphID: "graph-id", WebhookType: pixela.SelfSufficientIncrement},
+			"deploy": {GraphID: "deploys", Quantity: "1"},
+		},
+		api: api,
+		now: func() time.Time { return testNow },
+	}
+}
+
+func post(h http.Handler, body string, sign func(timestamp string, body []byte) string) *httptest.ResponseRecorder {
+	timestamp := strconv.FormatInt(testNow.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, sign(timestamp, []byte(body)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func signed(timestamp string, body []byte) string {
+	return Sign(secret, timestamp, body)
+}
+
+func TestHandlerRelay(t *testing.T) {
+	api := &apiMock{}
+	h := newTestHandler(api)
+
+	params := []struct {
+		body       string
+		statusCode int
+	}{
+		{body: `{"event":"push","id":"1"}`, statusCode: http.StatusOK},
+		{body: `{"event":"push","id":"2"}`, statusCode: http.StatusOK},
+		{body: `{"event":"deploy","id":"3"}`, statusCode: http.StatusOK},
+		{body: `{"event":"deploy","id":"4","quantity":"2.5"}`, statusCode: http.StatusOK},
+		{body: `{"event":"deploy","id":"4","quantity":"2.5"}`, statusCode: http.StatusOK},
+		{body: `{"event":"deploy","id":"5","quantity":"x"}`, statusCode: http.StatusBadRequest},
+		{body: `{"event":"unknown","id":"6"}`, statusCode: http.StatusNotFound},
+	}
+	for _, p := range params {
+		if rec := post(h, p.body, signed); rec.Code != p.statusCode {
+			t.Errorf("%s: got: %d\nwant: %d", p.body, rec.Code, p.statusCode)
+		}
+	}
+
+	expect := []string{"webhooks", "invoke hash", "invoke hash", "add deploys 1", "add deploys 2.5"}
+	if reflect.DeepEqual(api.calls, expect) == false {
+		t.Errorf("got: %v\nwant: %v", api.calls, expect)
+	}
+}
+
+func TestHandlerRelayRecreatedWebhook(t *testing.T) {
+	api := &apiMock{}
+	h := newTestHandler(api)
+	if rec := post(h, `{"event":"push","id":"1"}`, signed); rec.Code != http.StatusOK {
+		t.Fatalf("got: %d\nwant: %d", rec.Code, http.StatusOK)
+	}
+
+	// The webhook is deleted and created again with another hash.
+	api.hash = "new-hash"
+	body := `{"event":"push","id":"2"}`
+	if rec := post(h, body, signed); rec.Code != http.StatusBadGateway {
+		t.Errorf("got: %d\nwant: %d", rec.Code, http.StatusBadGateway)
+	}
+	if rec := post(h, body, signed); rec.Code != http.StatusOK {
+		t.Errorf("retry: got: %d\nwant: %d", rec.Code, http.StatusOK)
+	}
+
+	expect := []string{"webhooks", "invoke hash", "invoke hash", "webhooks", "invoke new-hash"}
+	if reflect.DeepEqual(api.calls, expect) == false {
+		t.Errorf("got: %v\nwant: %v", api.calls, expect)
+	}
+}
+
+func TestHandlerAuth(t *testing.T) {
+	api := &apiMock{}
+	h := newTestHandler(api)
+	body := `{"event":"push","id":"1"}`
+
+	rec := post(h, body, func(timestamp string, body []byte) string { return Sign([]byte("wrong"), timestamp, body) })
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("got: %d\nwant: %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	h.now = func() time.Time { return testNow.Add(time.Hour) }
+	if rec := post(h, body, signed); rec.Code != http.StatusUnauthorized {
+		t.Errorf("got: %d\nwant: %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got: %d\nwant: %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	if len(api.calls) != 0 {
+		t.Errorf("got: %v\nwant: no calls", api.calls)
+	}
+}
+
+func TestHandlerEmptySecret(t *testing.T) {
+	api := &apiMock{}
+	h := newTestHandler(api)
+	h.Secret = nil
+
+	rec := post(h, `{"event":"push","id":"1"}`, func(timestamp string, body []byte) string { return Sign(nil, timestamp, body) })
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("got: %d\nwant: %d", rec.Code, http.StatusUnauthorized)
+	}
+	if len(api.calls) != 0 {
+		t.Errorf("got: %v\nwant: no calls", api.calls)
+	}
+}
+
+func TestHandlerRateLimit(t *testing.T) {
+	api := &apiMock{}
+	h := newTestHandler(api)
+	h.Rate = 1
+	h.Burst = 2
+
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		codes = append(codes, post(h, `{"event":"deploy","id":"`+strconv.Itoa(i)+`"}`, signed).Code)
+	}
+	expect := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	if reflect.DeepEqual(codes, expect) == false {
+		t.Errorf("got: %v\nwant: %v", codes, expect)
+	}
+
+	// The rate limited delivery can be retried.
+	testNow = testNow.Add(time.Second)
+	defer func() { testNow = testNow.Add(-time.Second) }()
+	if rec := post(h, `{"event":"deploy","id":"2"}`, signed); rec.Code != http.StatusOK {
+		t.Errorf("got: %d\nwant: %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHandlerRelayFailure(t *testing.T) {
+	api := &apiMock{err: errors.New("unavailable")}
+	h := newTestHandler(api)
+
+	body := `{"event":"deploy","id":"1"}`
+	for i := 0; i < 2; i++ {
+		if rec := post(h, body, signed); rec.Code != http.StatusBadGateway {
+			t.Errorf("got: %d\nwant: %d", rec.Code, http.StatusBadGateway)
+		}
+	}
+	if len(api.calls) != 2 {
+		t.Errorf("got: %v\nwant: 2 calls", api.calls)
+	}
+}
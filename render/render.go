@@ -0,0 +1,247 @@
+// Package render renders Pixela style graphs locally from a graph definition and pixels.
+//
+// The rendered SVG has the same data-date and data-count attributes as the one of Pixela,
+// so it can be read back by pixela.ParseSVG.
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+	"github.com/pkg/errors"
+)
+
+const (
+	dateFormat = "20060102"
+	cellSize   = 10
+	cellStep   = 12
+	margin     = 20
+)
+
+// The number of days drawn in each mode.
+const (
+	defaultDays = 53 * 7
+	shortDays   = 13 * 7
+	badgeDays   = 7 * 7
+	lineDays    = 90
+)
+
+// palettes are the colors of each graph color from no pixel to the largest quantity.
+var palettes = map[string][]string{
+	pixela.ColorShibafu: {"#eeeeee", "#c6e48b", "#7bc96f", "#239a3b", "#196127"},
+	pixela.ColorMomiji:  {"#eeeeee", "#ffd4d4", "#ff8a8a", "#e0323c", "#a8101a"},
+	pixela.ColorSora:    {"#eeeeee", "#c6dcff", "#7aaaf5", "#2f6fd6", "#133f91"},
+	pixela.ColorIchou:   {"#eeeeee", "#fff3b0", "#ffe066", "#f4c20d", "#b38b00"},
+	pixela.ColorAjisai:  {"#eeeeee", "#e3d4ff", "#b595f5", "#7d4fd6", "#4b2191"},
+	pixela.ColorKuro:    {"#eeeeee", "#cccccc", "#999999", "#555555", "#111111"},
+}
+
+// Options are the options of rendering.
+type Options struct {
+	// Mode is the display mode: "" (default), pixela.ModeShort, pixela.ModeBadge or pixela.ModeLine.
+	Mode string
+	// Date is the last date drawn in yyyyMMdd format. If empty, today in the timezone of the graph is used.
+	Date string
+	// StartOfWeek is the weekday drawn in the first row. Sunday by default.
+	StartOfWeek time.Weekday
+
+	now func() time.Time
+}
+
+// SVG renders the graph in SVG format.
+func SVG(w io.Writer, definition *pixela.GraphDefinition, pixels []pixela.PixelValue, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	palette, ok := palettes[definition.Color]
+	if ok == false {
+		palette = palettes[pixela.ColorShibafu]
+	}
+
+	last, err := lastDate(definition, opts)
+	if err != nil {
+		return err
+	}
+	quantities, err := parseQuantities(pixels)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	switch opts.Mode {
+	case "":
+		writeHeatmap(bw, quantities, palette, last, defaultDays, opts.StartOfWeek)
+	case pixela.ModeShort:
+		writeHeatmap(bw, quantities, palette, last, shortDays, opts.StartOfWeek)
+	case pixela.ModeBadge:
+		writeBadge(bw, definition, quantities, palette, last)
+	case pixela.ModeLine:
+		writeLine(bw, quantities, palette, last)
+	default:
+		return errors.Errorf("unsupported mode: %s", opts.Mode)
+	}
+	return errors.Wrap(bw.Flush(), "failed to write svg")
+}
+
+// HTML renders the graph in a standalone HTML page for previews.
+func HTML(w io.Writer, definition *pixela.GraphDefinition, pixels []pixela.PixelValue, opts *Options) error {
+	title := html.EscapeString(definition.Name)
+	if _, err := fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>%s</title></head>\n<body>\n<h1>%s</h1>\n", title, title); err != nil {
+		return errors.Wrap(err, "failed to write html")
+	}
+	if err := SVG(w, definition, pixels, opts); err != nil {
+		return err
+	}
+	_, err := fmt.Fprint(w, "\n</body>\n</html>\n")
+	return errors.Wrap(err, "failed to write html")
+}
+
+func lastDate(definition *pixela.GraphDefinition, opts *Options) (time.Time, error) {
+	if opts.Date != "" {
+		last, err := time.Parse(dateFormat, opts.Date)
+		return last, errors.Wrapf(err, "failed to parse date")
+	}
+
+	location := time.UTC
+	if definition.TimeZone != "" {
+		l, err := time.LoadLocation(definition.TimeZone)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "failed to load timezone")
+		}
+		location = l
+	}
+	now := time.Now
+	if opts.now != nil {
+		now = opts.now
+	}
+	y, m, d := now().In(location).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
+}
+
+func parseQuantities(pixels []pixela.PixelValue) (map[string]float64, error) {
+	quantities := map[string]float64{}
+	for _, p := range pixels {
+		q, err := strconv.ParseFloat(p.Quantity, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse quantity of %s", p.Date)
+		}
+		quantities[p.Date] = q
+	}
+	return quantities, nil
+}
+
+// color returns the color of the quantity relative to the largest quantity.
+func color(palette []string, quantity, max float64) string {
+	if quantity <= 0 || max <= 0 {
+		return palette[0]
+	}
+	level := int(math.Ceil(quantity / max * float64(len(palette)-1)))
+	if level >= len(palette) {
+		level = len(palette) - 1
+	}
+	return palette[level]
+}
+
+func maxQuantity(quantities map[string]float64, first, last time.Time) float64 {
+	var max float64
+	for d := first; d.After(last) == false; d = d.AddDate(0, 0, 1) {
+		max = math.Max(max, quantities[d.Format(dateFormat)])
+	}
+	return max
+}
+
+func formatQuantity(q float64) string {
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
+
+// writeHeatmap draws a column per week and a row per weekday starting at start.
+func writeHeatmap(w io.Writer, quantities map[string]float64, palette []string, last time.Time, days int, start time.Weekday) {
+	first := last.AddDate(0, 0, -(days - 1))
+	// The first column starts at the start of the week.
+	first = first.AddDate(0, 0, -((int(first.Weekday()) - int(start) + 7) % 7))
+	max := maxQuantity(quantities, first, last)
+
+	weeks := int(last.Sub(first).Hours()/24)/7 + 1
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n", weeks*cellStep+2*margin, 7*cellStep+2*margin)
+	fmt.Fprintf(w, `<g transform="translate(%d, %d)">`+"\n", margin, margin)
+	for d := first; d.After(last) == false; d = d.AddDate(0, 0, 1) {
+		offset := int(d.Sub(first).Hours() / 24)
+		date := d.Format(dateFormat)
+		q := quantities[date]
+		fmt.Fprintf(w, `<rect class="each-day" width="%d" height="%d" x="%d" y="%d" fill="%s" data-count="%s" data-date="%s"/>`+"\n",
+			cellSize, cellSize, offset/7*cellStep, offset%7*cellStep, color(palette, q, max), formatQuantity(q), date)
+	}
+	fmt.Fprint(w, "</g>\n</svg>\n")
+}
+
+// writeBadge draws 7 pixels, each of them is the sum of 7 days.
+func writeBadge(w io.Writer, definition *pixela.GraphDefinition, quantities map[string]float64, palette []string, last time.Time) {
+	sums := make([]float64, badgeDays/7)
+	var max float64
+	for i := range sums {
+		end := last.AddDate(0, 0, -7*(len(sums)-1-i))
+		for d := end.AddDate(0, 0, -6); d.After(end) == false; d = d.AddDate(0, 0, 1) {
+			sums[i] += quantities[d.Format(dateFormat)]
+		}
+		max = math.Max(max, sums[i])
+	}
+
+	label := html.EscapeString(definition.ID)
+	labelWidth := 7*len(definition.ID) + 10
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20">`+"\n", labelWidth+len(sums)*cellStep+4)
+	fmt.Fprintf(w, `<rect width="%d" height="20" fill="#555555"/>`+"\n", labelWidth)
+	fmt.Fprintf(w, `<text x="5" y="14" fill="#ffffff" font-size="11">%s</text>`+"\n", label)
+	fmt.Fprintf(w, `<g transform="translate(%d, 5)">`+"\n", labelWidth+2)
+	for i, sum := range sums {
+		end := last.AddDate(0, 0, -7*(len(sums)-1-i))
+		fmt.Fprintf(w, `<rect width="%d" height="%d" x="%d" y="0" fill="%s" data-count="%s" data-date="%s"/>`+"\n",
+			cellSize, cellSize, i*cellStep, color(palette, sum, max), formatQuantity(sum), end.Format(dateFormat))
+	}
+	fmt.Fprint(w, "</g>\n</svg>\n")
+}
+
+// writeLine draws a line chart of the days.
+func writeLine(w io.Writer, quantities map[string]float64, palette []string, last time.Time) {
+	const height = 100
+	first := last.AddDate(0, 0, -(lineDays - 1))
+	max := maxQuantity(quantities, first, last)
+	stroke := palette[len(palette)-1]
+
+	type point struct {
+		x, y int
+		date string
+		q    float64
+	}
+	var points []point
+	for d := first; d.After(last) == false; d = d.AddDate(0, 0, 1) {
+		date := d.Format(dateFormat)
+		q := quantities[date]
+		y := height
+		if max > 0 {
+			y = height - int(q/max*height)
+		}
+		points = append(points, point{x: len(points) * 6, y: y, date: date, q: q})
+	}
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`+"\n", len(points)*6+2*margin, height+2*margin)
+	fmt.Fprintf(w, `<g transform="translate(%d, %d)">`+"\n", margin, margin)
+	fmt.Fprint(w, `<polyline fill="none" stroke="`+stroke+`" points="`)
+	for i, p := range points {
+		if i > 0 {
+			fmt.Fprint(w, " ")
+		}
+		fmt.Fprintf(w, "%d,%d", p.x, p.y)
+	}
+	fmt.Fprint(w, `"/>`+"\n")
+	for _, p := range points {
+		fmt.Fprintf(w, `<circle cx="%d" cy="%d" r="2" fill="%s" data-count="%s" data-date="%s"/>`+"\n",
+			p.x, p.y, stroke, formatQuantity(p.q), p.date)
+	}
+	fmt.Fprint(w, "</g>\n</svg>\n")
+}
@@ -0,0 +1,135 @@
+package render
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pixela "github.com/ebc-2in2crc/pixela-client-go"
+)
+
+var testDefinition = &pixela.GraphDefinition{ID: "graph-id", Name: "graph-name", Type: pixela.TypeInt, Color: pixela.ColorSora, TimeZone: "UTC"}
+
+var testPixels = []pixela.PixelValue{
+	{Date: "20180910", Quantity: "3"},
+	{Date: "20180914", Quantity: "12"},
+	{Date: "20180915", Quantity: "6"},
+}
+
+func render(t *testing.T, opts *Options) []pixela.PixelValue {
+	var buf bytes.Buffer
+	if err := SVG(&buf, testDefinition, testPixels, opts); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	values, err := pixela.ParseSVG(&buf)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	return values
+}
+
+func nonZero(values []pixela.PixelValue) []pixela.PixelValue {
+	var result []pixela.PixelValue
+	for _, v := range values {
+		if v.Quantity != "0" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func TestSVGRoundTrip(t *testing.T) {
+	for _, mode := range []string{"", pixela.ModeShort, pixela.ModeLine} {
+		values := render(t, &Options{Mode: mode, Date: "20180915"})
+		if actual := nonZero(values); reflect.DeepEqual(actual, testPixels) == false {
+			t.Errorf("mode %q: got: %v\nwant: %v", mode, actual, testPixels)
+		}
+		if last := values[len(values)-1].Date; last != "20180915" {
+			t.Errorf("mode %q: last date: %s\nwant: 20180915", mode, last)
+		}
+	}
+}
+
+func TestSVGStartOfWeek(t *testing.T) {
+	// 20180915 is Saturday.
+	params := []struct {
+		start  time.Weekday
+		expect string
+	}{
+		{start: time.Sunday, expect: "20180617"},
+		{start: time.Monday, expect: "20180611"},
+	}
+
+	for _, p := range params {
+		values := render(t, &Options{Mode: pixela.ModeShort, Date: "20180915", StartOfWeek: p.start})
+		if values[0].Date != p.expect {
+			t.Errorf("start %v: first date: %s\nwant: %s", p.start, values[0].Date, p.expect)
+		}
+	}
+}
+
+func TestSVGBadge(t *testing.T) {
+	values := render(t, &Options{Mode: pixela.ModeBadge, Date: "20180915"})
+	if len(values) != 7 {
+		t.Fatalf("got: %v\nwant: 7 pixels", values)
+	}
+	expect := pixela.PixelValue{Date: "20180915", Quantity: "21"}
+	if values[6] != expect {
+		t.Errorf("got: %v\nwant: %v", values[6], expect)
+	}
+}
+
+func TestSVGTimezone(t *testing.T) {
+	definition := *testDefinition
+	definition.TimeZone = "Asia/Tokyo"
+	now := func() time.Time { return time.Date(2018, 9, 15, 20, 0, 0, 0, time.UTC) }
+
+	var buf bytes.Buffer
+	if err := SVG(&buf, &definition, testPixels, &Options{Mode: pixela.ModeShort, now: now}); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	values, _ := pixela.ParseSVG(&buf)
+	if last := values[len(values)-1].Date; last != "20180916" {
+		t.Errorf("last date: %s\nwant: 20180916", last)
+	}
+}
+
+func TestSVGColor(t *testing.T) {
+	var buf bytes.Buffer
+	if err := SVG(&buf, testDefinition, testPixels, &Options{Date: "20180915"}); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	palette := palettes[pixela.ColorSora]
+	for _, expect := range []string{
+		`fill="` + palette[1] + `" data-count="3"`,
+		`fill="` + palette[4] + `" data-count="12"`,
+		`fill="` + palette[2] + `" data-count="6"`,
+	} {
+		if strings.Contains(buf.String(), expect) == false {
+			t.Errorf("got: no %s", expect)
+		}
+	}
+}
+
+func TestSVGFail(t *testing.T) {
+	var buf bytes.Buffer
+	if err := SVG(&buf, testDefinition, testPixels, &Options{Mode: "unknown"}); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+	pixels := []pixela.PixelValue{{Date: "20180915", Quantity: "x"}}
+	if err := SVG(&buf, testDefinition, pixels, nil); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := HTML(&buf, testDefinition, testPixels, &Options{Date: "20180915"}); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	if strings.Contains(buf.String(), "<title>graph-name</title>") == false || strings.Contains(buf.String(), "<svg") == false {
+		t.Errorf("got: %s", buf.String())
+	}
+}
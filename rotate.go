@@ -0,0 +1,175 @@
+package pixela
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// TokenGenerator generates a new token for RotateToken.
+type TokenGenerator interface {
+	Generate() (string, error)
+}
+
+// TokenGeneratorFunc is a function that implements TokenGenerator.
+type TokenGeneratorFunc func() (string, error)
+
+// Generate calls f.
+func (f TokenGeneratorFunc) Generate() (string, error) {
+	return f()
+}
+
+const (
+	minTokenLength     = 8
+	maxTokenLength     = 128
+	defaultTokenLength = 64
+	tokenCharacters    = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+)
+
+// RandomTokenGenerator generates a token from letters, digits, "-" and "_" with crypto/rand.
+type RandomTokenGenerator struct {
+	// Length is the length of the token. If 0, 64 is used.
+	Length int
+}
+
+// Generate returns a new random token.
+func (g *RandomTokenGenerator) Generate() (string, error) {
+	length := g.Length
+	if length == 0 {
+		length = defaultTokenLength
+	}
+	if length < minTokenLength || length > maxTokenLength {
+		return "", errors.Errorf("token length must be between %d and %d: %d", minTokenLength, maxTokenLength, length)
+	}
+
+	max := big.NewInt(int64(len(tokenCharacters)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to generate random number")
+		}
+		b[i] = tokenCharacters[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// RotationResult is the result of RotateToken.
+type RotationResult struct {
+	// ActiveToken is the token that is valid now. The Client uses it after RotateToken returns.
+	ActiveToken string
+	// Rotated reports whether ActiveToken is the new token.
+	Rotated bool
+}
+
+// RotateToken updates the token to a new one generated by the generator and checks it with an authenticated read.
+// If the update response is lost or the check fails, both the old and the new tokens are probed
+// and the Client uses the one that is valid.
+// If generator is nil, RandomTokenGenerator is used.
+func (c *Client) RotateToken(ctx context.Context, generator TokenGenerator) (*RotationResult, error) {
+	if generator == nil {
+		generator = &RandomTokenGenerator{}
+	}
+	newToken, err := generator.Generate()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate token")
+	}
+	if err := ValidateToken(newToken); err != nil {
+		return nil, errors.Wrap(err, "generated token is invalid")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	oldToken := c.CurrentToken()
+	result, err := c.UpdateUser(newToken, "")
+	if err == nil && result.IsSuccess == false {
+		return &RotationResult{ActiveToken: oldToken}, errors.Errorf("failed to update token: %s", result.Message)
+	}
+	if err == nil {
+		if ok, err := c.verifyToken(newToken); err == nil && ok {
+			return &RotationResult{ActiveToken: newToken, Rotated: true}, nil
+		}
+	}
+
+	return c.resolveToken(ctx, oldToken, newToken)
+}
+
+// RecoverToken resolves the token rotation interrupted by a crash.
+// If the credentials of the Client have a pending token, both tokens are probed
+// and the valid one is used and stored.
+func (c *Client) RecoverToken(ctx context.Context) (*RotationResult, error) {
+	if c.credentials == nil {
+		return &RotationResult{ActiveToken: c.CurrentToken()}, nil
+	}
+	credentials, err := c.credentials.Retrieve()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve credentials")
+	}
+	if credentials.PendingToken == "" {
+		return &RotationResult{ActiveToken: c.CurrentToken()}, nil
+	}
+
+	return c.resolveToken(ctx, credentials.Token, credentials.PendingToken)
+}
+
+// resolveToken probes the new token and then the old token, and switches the Client to the valid one.
+func (c *Client) resolveToken(ctx context.Context, oldToken, newToken string) (*RotationResult, error) {
+	for _, candidate := range []string{newToken, oldToken} {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		ok, err := c.verifyToken(candidate)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to verify token")
+		}
+		if ok == false {
+			continue
+		}
+
+		c.setToken(candidate)
+		if store, persist := c.credentials.(CredentialsStore); persist {
+			if err := store.Store(&Credentials{UserName: c.UserName, Token: candidate}); err != nil {
+				return nil, errors.Wrap(err, "failed to store token")
+			}
+		}
+		return &RotationResult{ActiveToken: candidate, Rotated: candidate == newToken}, nil
+	}
+	return nil, errors.New("neither the old nor the new token is valid")
+}
+
+// verifyToken reports whether the token is valid by getting all graph definitions with it.
+// The request goes through the request path of c, bypassing the cache, so that it is logged and uses the transport of c.
+// A failure other than an authentication failure, such as 503, is returned as an error.
+func (c *Client) verifyToken(token string) (bool, error) {
+	param, err := c.graph("").createGetAllRequestParameter()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create get all graph parameter")
+	}
+	param.Operation = "Client.VerifyToken"
+	param.Header[userToken] = token
+
+	var statusCode int
+	var b []byte
+	err = c.do(param, func(resp *http.Response) error {
+		var err error
+		statusCode = resp.StatusCode
+		b, err = readBody(resp)
+		return err
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to do request")
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return false, nil
+	case statusCode >= 300:
+		return false, errors.Errorf("failed to call API: %d: %s", statusCode, param.redact(string(b)))
+	}
+	return true, nil
+}
@@ -0,0 +1,168 @@
+package pixela
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const newToken = "new-token-for-rotation"
+
+// newRotationMock returns a mock that responds to the token update with updateBody
+// and accepts only validToken for authenticated reads.
+func newRotationMock(updateStatus int, updateBody string, validToken string) *httpClientMock {
+	return &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		if req.Method == http.MethodPut {
+			return updateStatus, []byte(updateBody)
+		}
+		if req.Header.Get(userToken) != validToken {
+			return http.StatusUnauthorized, []byte(`{"message":"User token is invalid.","isSuccess":false}`)
+		}
+		return http.StatusOK, []byte(`{"graphs":[]}`)
+	}}
+}
+
+func TestClientRotateToken(t *testing.T) {
+	clientMock = newRotationMock(http.StatusOK, `{"message":"Success.","isSuccess":true}`, newToken)
+
+	client := NewClient(userName, token)
+	result, err := client.RotateToken(context.Background(), TokenGeneratorFunc(func() (string, error) {
+		return newToken, nil
+	}))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := RotationResult{ActiveToken: newToken, Rotated: true}
+	if *result != expect {
+		t.Errorf("got: %v\nwant: %v", *result, expect)
+	}
+	if client.Token != newToken {
+		t.Errorf("Token: %s\nwant: %s", client.Token, newToken)
+	}
+}
+
+func TestClientRotateTokenLostResponse(t *testing.T) {
+	clientMock = newRotationMock(http.StatusBadGateway, `502 Bad Gateway`, newToken)
+
+	store := &credentialsStoreMock{credentials: &Credentials{UserName: userName, Token: token}}
+	client, err := NewClientWithCredentials(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.RotateToken(context.Background(), TokenGeneratorFunc(func() (string, error) {
+		return newToken, nil
+	}))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := RotationResult{ActiveToken: newToken, Rotated: true}
+	if *result != expect {
+		t.Errorf("got: %v\nwant: %v", *result, expect)
+	}
+	stored := Credentials{UserName: userName, Token: newToken}
+	if store.stored[len(store.stored)-1] != stored {
+		t.Errorf("got: %v\nwant: %v", store.stored[len(store.stored)-1], stored)
+	}
+}
+
+func TestClientRotateTokenNotRotated(t *testing.T) {
+	clientMock = newRotationMock(http.StatusBadGateway, `502 Bad Gateway`, token)
+
+	client := NewClient(userName, token)
+	result, err := client.RotateToken(context.Background(), TokenGeneratorFunc(func() (string, error) {
+		return newToken, nil
+	}))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := RotationResult{ActiveToken: token, Rotated: false}
+	if *result != expect {
+		t.Errorf("got: %v\nwant: %v", *result, expect)
+	}
+	if client.Token != token {
+		t.Errorf("Token: %s\nwant: %s", client.Token, token)
+	}
+}
+
+func TestClientRotateTokenInvalidToken(t *testing.T) {
+	client := NewClient(userName, token)
+	_, err := client.RotateToken(context.Background(), TokenGeneratorFunc(func() (string, error) {
+		return "short", nil
+	}))
+	if err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestClientRecoverToken(t *testing.T) {
+	clientMock = newRotationMock(http.StatusOK, "", newToken)
+
+	store := &credentialsStoreMock{credentials: &Credentials{UserName: userName, Token: token, PendingToken: newToken}}
+	client, err := NewClientWithCredentials(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.RecoverToken(context.Background())
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := RotationResult{ActiveToken: newToken, Rotated: true}
+	if *result != expect {
+		t.Errorf("got: %v\nwant: %v", *result, expect)
+	}
+}
+
+func TestRandomTokenGenerator(t *testing.T) {
+	token, err := (&RandomTokenGenerator{}).Generate()
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+	if len(token) != defaultTokenLength {
+		t.Errorf("length: %d\nwant: %d", len(token), defaultTokenLength)
+	}
+	if err := ValidateToken(token); err != nil {
+		t.Errorf("got: %v\nwant: nil", err)
+	}
+
+	if _, err := (&RandomTokenGenerator{Length: 7}).Generate(); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestClientRecoverTokenUnavailable(t *testing.T) {
+	clientMock = &httpClientMock{
+		statusCode: http.StatusServiceUnavailable,
+		body:       []byte(`{"message":"Please retry this request.","isSuccess":false}`),
+	}
+
+	store := &credentialsStoreMock{credentials: &Credentials{UserName: userName, Token: token, PendingToken: newToken}}
+	client, err := NewClientWithCredentials(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.RecoverToken(context.Background()); err == nil || strings.Contains(err.Error(), "neither") {
+		t.Errorf("got: %v\nwant: the 503 error", err)
+	}
+	if client.CurrentToken() != token || len(store.stored) != 0 {
+		t.Errorf("got: %s, %v\nwant: the token unchanged", client.CurrentToken(), store.stored)
+	}
+}
+
+func TestClientVerifyTokenIsLogged(t *testing.T) {
+	clientMock = newRotationMock(http.StatusOK, "", newToken)
+
+	recorder := &logRecorder{}
+	client := NewClient(userName, token)
+	client.EnableLogging(recorder, false)
+	if ok, err := client.verifyToken(newToken); ok == false || err != nil {
+		t.Errorf("got: %v, %v\nwant: true, nil", ok, err)
+	}
+	if len(recorder.entries) != 1 || recorder.entries[0].Operation != "Client.VerifyToken" {
+		t.Errorf("got: %+v\nwant: the verification logged", recorder.entries)
+	}
+}
@@ -0,0 +1,58 @@
+package pixela
+
+import (
+	"context"
+	"io"
+)
+
+// GraphService is the graph API. Graph implements it.
+type GraphService interface {
+	Create(name, unit, quantityType, color, timezone, selfSufficient string, isSecret, publishOptionalData bool) (*Result, error)
+	GetAll() (*GraphDefinitions, error)
+	GetSVG(date, mode string) (string, error)
+	GetSVGConditional(date, mode string) (*SVG, error)
+	URL(mode string) string
+	GraphsURL() string
+	Stats() (*Stats, error)
+	Update(name, unit, color, timezone string, purgeCacheUrls []string, selfSufficient string, isSecret bool, publishOptionalData bool) (*Result, error)
+	Delete() (*Result, error)
+	GetPixelDates(from, to string) (*Pixels, error)
+	Export(ctx context.Context, w io.Writer, format string) error
+	Import(ctx context.Context, r io.Reader, format string, dryRun bool) (*ImportDiff, error)
+	Sync(ctx context.Context, source Source, opts *SyncOptions) (*SyncDiff, error)
+}
+
+// PixelService is the pixel API. Pixel implements it.
+type PixelService interface {
+	Create(date string, quantity, optionalData string) (*Result, error)
+	CreateWithOptionalData(date, quantity string, v interface{}, opts *OptionalDataOptions) (*Result, error)
+	Increment() (*Result, error)
+	Decrement() (*Result, error)
+	Add(quantity string) (*Result, error)
+	Get(date string) (*Quantity, error)
+	Update(date, quantity, optionalData string) (*Result, error)
+	UpdateWithOptionalData(date, quantity string, v interface{}, opts *OptionalDataOptions) (*Result, error)
+	Delete(date string) (*Result, error)
+}
+
+// WebhookService is the webhook API. Webhook implements it.
+type WebhookService interface {
+	Create(graphID, selfSufficient string) (*WebhookCreateResult, error)
+	GetAll() (*WebhookDefinitions, error)
+	Delete(webhookHash string) (*Result, error)
+	Invoke(webhookHash string) (*Result, error)
+}
+
+// UserService is the user API. Client implements it.
+type UserService interface {
+	CreateUser(agreeTermsOfService, notMinor bool, thanksCode string) (*Result, error)
+	UpdateUser(newToken, thanksCode string) (*Result, error)
+	DeleteUser() (*Result, error)
+}
+
+var (
+	_ GraphService   = (*Graph)(nil)
+	_ PixelService   = (*Pixel)(nil)
+	_ WebhookService = (*Webhook)(nil)
+	_ UserService    = (*Client)(nil)
+)
@@ -0,0 +1,64 @@
+package pixela
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	svgDateAttr  = "data-date"
+	svgCountAttr = "data-count"
+)
+
+// ParseSVG parses a graph expressed in SVG format returned by Graph.GetSVG and returns the pixels drawn in it in date order.
+// Every element that has data-date and data-count attributes is a pixel.
+// It is tested with the default and short modes only; the badge and line modes are not supported.
+// Days without a pixel are drawn with the count 0 and are included as they are.
+func ParseSVG(r io.Reader) ([]PixelValue, error) {
+	decoder := xml.NewDecoder(r)
+	dates := map[string]bool{}
+	var values []PixelValue
+	for {
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse svg")
+		}
+
+		element, ok := t.(xml.StartElement)
+		if ok == false {
+			continue
+		}
+		value, ok := svgPixelValue(element)
+		if ok == false || dates[value.Date] {
+			continue
+		}
+		if err := ValidateDate(value.Date); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse svg element %s", element.Name.Local)
+		}
+		dates[value.Date] = true
+		values = append(values, value)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i].Date < values[j].Date })
+	return values, nil
+}
+
+func svgPixelValue(element xml.StartElement) (PixelValue, bool) {
+	var value PixelValue
+	var hasDate, hasCount bool
+	for _, attr := range element.Attr {
+		switch attr.Name.Local {
+		case svgDateAttr:
+			value.Date, hasDate = attr.Value, true
+		case svgCountAttr:
+			value.Quantity, hasCount = attr.Value, true
+		}
+	}
+	return value, hasDate && hasCount
+}
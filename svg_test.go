@@ -0,0 +1,79 @@
+package pixela
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The fixtures are synthetic: they are written after the markup of Graph.GetSVG for a graph of int type
+// at the date 20180915, not captured from Pixela. They should be replaced by captured responses, for example:
+//
+//	curl -o testdata/svg/short.svg 'https://pixe.la/v1/users/<user>/graphs/<graph>?date=20180915&mode=short'
+func TestParseSVG(t *testing.T) {
+	params := []struct {
+		file  string
+		count int
+		first PixelValue
+		last  PixelValue
+		total int
+	}{
+		{file: "default.svg", count: 371, first: PixelValue{Date: "20170910", Quantity: "0"}, last: PixelValue{Date: "20180915", Quantity: "9"}, total: 1691},
+		{file: "short.svg", count: 91, first: PixelValue{Date: "20180617", Quantity: "0"}, last: PixelValue{Date: "20180915", Quantity: "9"}, total: 420},
+	}
+
+	for _, p := range params {
+		f, err := os.Open(filepath.Join("testdata", "svg", p.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := ParseSVG(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: got: %v\nwant: nil", p.file, err)
+			continue
+		}
+		if len(actual) != p.count {
+			t.Errorf("%s: got: %d pixels\nwant: %d", p.file, len(actual), p.count)
+			continue
+		}
+		if actual[0] != p.first || actual[len(actual)-1] != p.last {
+			t.Errorf("%s: got: %v ... %v\nwant: %v ... %v", p.file, actual[0], actual[len(actual)-1], p.first, p.last)
+		}
+
+		total := 0
+		for i, v := range actual {
+			n, err := strconv.Atoi(v.Quantity)
+			if err != nil {
+				t.Errorf("%s: got: %v\nwant: int quantity", p.file, v)
+			}
+			total += n
+			if i == 0 {
+				continue
+			}
+			prev, _ := time.Parse(dateFormat, actual[i-1].Date)
+			if next := prev.AddDate(0, 0, 1).Format(dateFormat); v.Date != next {
+				t.Errorf("%s: got: %s after %s\nwant: %s", p.file, v.Date, actual[i-1].Date, next)
+			}
+		}
+		if total != p.total {
+			t.Errorf("%s: got: total %d\nwant: %d", p.file, total, p.total)
+		}
+	}
+}
+
+func TestParseSVGFail(t *testing.T) {
+	params := []string{
+		`<svg><rect data-count="1" data-date="2018-09-15"/></svg>`,
+		`<svg><rect data-count="1" data-date="20180915"></svg>`,
+	}
+
+	for _, p := range params {
+		if _, err := ParseSVG(strings.NewReader(p)); err == nil {
+			t.Errorf("%s: got: nil\nwant: error", p)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package pixela
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Source yields the pixels to sync into a graph, for example from a SQL query, an exporter or a log file.
+type Source interface {
+	// Pixels returns the pixels of the source. Dates are in the timezone of the graph given as location.
+	// A date may appear more than once; the pixels of a date are aggregated by SyncOptions.Aggregation.
+	Pixels(ctx context.Context, location *time.Location) ([]PixelValue, error)
+}
+
+// SourceFunc is a function that is a Source.
+type SourceFunc func(ctx context.Context, location *time.Location) ([]PixelValue, error)
+
+// Pixels calls f.
+func (f SourceFunc) Pixels(ctx context.Context, location *time.Location) ([]PixelValue, error) {
+	return f(ctx, location)
+}
+
+// Aggregations of the pixels of a date yielded by a Source.
+const (
+	AggregateSum   = "sum"
+	AggregateMax   = "max"
+	AggregateLast  = "last"
+	AggregateCount = "count"
+)
+
+// SyncOptions are the options of Sync.
+type SyncOptions struct {
+	// Aggregation is how the pixels of a date are aggregated. If empty, AggregateSum is used.
+	Aggregation string
+	// From and To are the range of dates in yyyyMMdd format where the pixels of the graph that the source does not have are deleted.
+	// If empty, the first and the last date of the source are used.
+	From string
+	To   string
+	// DryRun makes Sync only return the changes without applying them.
+	DryRun bool
+}
+
+// SyncDiff is the set of changes Sync applies to the graph.
+type SyncDiff struct {
+	ImportDiff
+	Delete []PixelValue
+}
+
+// Sync makes the pixels of the graph the same as the aggregated pixels of the source.
+// Only the pixels that differ are created, updated or deleted.
+// If the optional data of a source pixel is empty, the optional data of the graph pixel is kept.
+func (g *Graph) Sync(ctx context.Context, source Source, opts *SyncOptions) (*SyncDiff, error) {
+	return SyncGraph(ctx, g, g.pixel(), g.GraphID, source, opts)
+}
+
+// SyncGraph is Graph.Sync for any GraphService and PixelService of the graph, such as fakes.
+func SyncGraph(ctx context.Context, graph GraphService, pixel PixelService, graphID string, source Source, opts *SyncOptions) (*SyncDiff, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+
+	definition, err := graphDefinition(graph, graphID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get graph definition")
+	}
+	location := time.UTC
+	if definition.TimeZone != "" {
+		if location, err = time.LoadLocation(definition.TimeZone); err != nil {
+			return nil, errors.Wrapf(err, "failed to load timezone")
+		}
+	}
+
+	pixels, err := source.Pixels(ctx, location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read source")
+	}
+	values, err := aggregatePixels(pixels, opts.Aggregation, definition.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePixelValues(values, definition.Type); err != nil {
+		return nil, err
+	}
+
+	from, to := opts.From, opts.To
+	if len(values) > 0 {
+		if from == "" {
+			from = values[0].Date
+		}
+		if to == "" {
+			to = values[len(values)-1].Date
+		}
+	}
+
+	// Only the pixels that can be changed are read: the ones in the range and the ones of the source.
+	first, last := from, to
+	if len(values) > 0 {
+		if first == "" || values[0].Date < first {
+			first = values[0].Date
+		}
+		if last == "" || values[len(values)-1].Date > last {
+			last = values[len(values)-1].Date
+		}
+	}
+	var current []PixelValue
+	if first != "" && last != "" {
+		dates, err := pixelDatesBetween(ctx, graph, first, last)
+		if err != nil {
+			return nil, err
+		}
+		if current, err = getPixelValues(ctx, pixel, dates); err != nil {
+			return nil, err
+		}
+	}
+
+	diff := diffSyncPixels(current, values, from, to)
+	if opts.DryRun {
+		return diff, nil
+	}
+
+	return diff, applySyncDiff(ctx, pixel, diff)
+}
+
+// aggregatePixels aggregates the pixels per date and returns them in date order.
+func aggregatePixels(pixels []PixelValue, aggregation, quantityType string) ([]PixelValue, error) {
+	if aggregation == "" {
+		aggregation = AggregateSum
+	}
+
+	type group struct {
+		value PixelValue
+		sum   float64
+		max   float64
+		count int
+	}
+	groups := map[string]*group{}
+	var dates []string
+	for _, p := range pixels {
+		g, ok := groups[p.Date]
+		if ok == false {
+			g = &group{}
+			groups[p.Date] = g
+			dates = append(dates, p.Date)
+		}
+
+		if aggregation != AggregateCount && aggregation != AggregateLast {
+			q, err := strconv.ParseFloat(p.Quantity, 64)
+			if err != nil {
+				return nil, errors.Errorf("quantity is not a number: %s: %q", p.Date, p.Quantity)
+			}
+			if g.count == 0 || q > g.max {
+				g.max = q
+			}
+			g.sum += q
+		}
+		g.count++
+		g.value.Date = p.Date
+		g.value.Quantity = p.Quantity
+		if p.OptionalData != "" {
+			g.value.OptionalData = p.OptionalData
+		}
+	}
+
+	sort.Strings(dates)
+	values := make([]PixelValue, 0, len(dates))
+	for _, date := range dates {
+		g := groups[date]
+		var err error
+		switch aggregation {
+		case AggregateSum:
+			g.value.Quantity, err = formatQuantity(g.sum, quantityType)
+		case AggregateMax:
+			g.value.Quantity, err = formatQuantity(g.max, quantityType)
+		case AggregateCount:
+			g.value.Quantity = strconv.Itoa(g.count)
+		case AggregateLast:
+		default:
+			return nil, errors.Errorf("unsupported aggregation: %s", aggregation)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to aggregate pixels: %s", date)
+		}
+		values = append(values, g.value)
+	}
+	return values, nil
+}
+
+// formatQuantity formats q as a quantity of the type. It fails if the type is int and q is not an integer.
+func formatQuantity(q float64, quantityType string) (string, error) {
+	if quantityType == TypeInt {
+		if q != math.Trunc(q) || math.Abs(q) > math.MaxInt64 {
+			return "", errors.Errorf("quantity is not int: %v", q)
+		}
+		return strconv.FormatInt(int64(q), 10), nil
+	}
+	return strconv.FormatFloat(q, 'f', -1, 64), nil
+}
+
+// pixelDatesBetween returns the dates of the pixels of the graph from from to to, in date order.
+func pixelDatesBetween(ctx context.Context, graph GraphService, from, to string) ([]string, error) {
+	start, err := time.Parse(dateFormat, from)
+	if err != nil {
+		return nil, errors.Errorf("invalid date: %s", from)
+	}
+	end, err := time.Parse(dateFormat, to)
+	if err != nil {
+		return nil, errors.Errorf("invalid date: %s", to)
+	}
+
+	var dates []string
+	for start.After(end) == false {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Pixela does not accept a period greater than 365 days.
+		windowEnd := start.AddDate(0, 0, 364)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+		pixels, err := graph.GetPixelDates(start.Format(dateFormat), windowEnd.Format(dateFormat))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get pixel dates")
+		}
+		if pixels.IsSuccess == false {
+			return nil, errors.Errorf("failed to get pixel dates: %s", pixels.Message)
+		}
+		dates = append(dates, pixels.Pixels...)
+		start = windowEnd.AddDate(0, 0, 1)
+	}
+
+	sort.Strings(dates)
+	return dates, nil
+}
+
+// diffSyncPixels returns the changes to make current the same as values.
+// The pixels of current that values does not have are deleted if they are from from to to.
+func diffSyncPixels(current, values []PixelValue, from, to string) *SyncDiff {
+	diff := &SyncDiff{}
+	existing := make(map[string]PixelValue, len(current))
+	for _, c := range current {
+		existing[c.Date] = c
+	}
+
+	wanted := make(map[string]bool, len(values))
+	for _, v := range values {
+		wanted[v.Date] = true
+		before, ok := existing[v.Date]
+		if ok == false {
+			diff.Create = append(diff.Create, v)
+			continue
+		}
+		if v.OptionalData == "" {
+			v.OptionalData = before.OptionalData
+		}
+		if equalQuantity(before.Quantity, v.Quantity) && before.OptionalData == v.OptionalData {
+			diff.Unchanged = append(diff.Unchanged, v)
+			continue
+		}
+		diff.Update = append(diff.Update, PixelChange{Before: before, After: v})
+	}
+
+	if from == "" || to == "" {
+		return diff
+	}
+	for _, c := range current {
+		if c.Date >= from && c.Date <= to && wanted[c.Date] == false {
+			diff.Delete = append(diff.Delete, c)
+		}
+	}
+	return diff
+}
+
+func applySyncDiff(ctx context.Context, pixel PixelService, diff *SyncDiff) error {
+	for _, p := range diff.Create {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := checkResult(pixel.Create(p.Date, p.Quantity, p.OptionalData)); err != nil {
+			return errors.Wrapf(err, "failed to create pixel: %s", p.Date)
+		}
+	}
+	for _, c := range diff.Update {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := checkResult(pixel.Update(c.After.Date, c.After.Quantity, c.After.OptionalData)); err != nil {
+			return errors.Wrapf(err, "failed to update pixel: %s", c.After.Date)
+		}
+	}
+	for _, p := range diff.Delete {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := checkResult(pixel.Delete(p.Date)); err != nil {
+			return errors.Wrapf(err, "failed to delete pixel: %s", p.Date)
+		}
+	}
+	return nil
+}
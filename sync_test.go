@@ -0,0 +1,152 @@
+package pixela
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSource = SourceFunc(func(ctx context.Context, location *time.Location) ([]PixelValue, error) {
+	return []PixelValue{
+		{Date: "20180915", Quantity: "2"},
+		{Date: "20180917", Quantity: "1"},
+		{Date: "20180915", Quantity: "3"},
+	}, nil
+})
+
+func writeRequests(requests []string) []string {
+	var writes []string
+	for _, r := range requests {
+		if strings.HasPrefix(r, "GET ") == false {
+			writes = append(writes, r)
+		}
+	}
+	return writes
+}
+
+func TestGraphSync(t *testing.T) {
+	var requests []string
+	clientMock = newExportMock(map[string]string{"20180915": "5", "20180916": "3"}, &requests)
+
+	client := Client{UserName: userName, Token: token}
+	diff, err := client.Graph(graphID).Sync(context.Background(), testSource, nil)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := &SyncDiff{
+		ImportDiff: ImportDiff{
+			Create:    []PixelValue{{Date: "20180917", Quantity: "1"}},
+			Unchanged: []PixelValue{{Date: "20180915", Quantity: "5"}},
+		},
+		Delete: []PixelValue{{Date: "20180916", Quantity: "3"}},
+	}
+	if reflect.DeepEqual(diff, expect) == false {
+		t.Errorf("got: %v\nwant: %v", diff, expect)
+	}
+
+	writes := writeRequests(requests)
+	expectWrites := []string{
+		"POST /v1/users/user/graphs/graph-id",
+		"DELETE /v1/users/user/graphs/graph-id/20180916",
+	}
+	if reflect.DeepEqual(writes, expectWrites) == false {
+		t.Errorf("got: %v\nwant: %v", writes, expectWrites)
+	}
+}
+
+func TestGraphSyncDryRun(t *testing.T) {
+	var requests []string
+	clientMock = newExportMock(map[string]string{"20180915": "5", "20180916": "3"}, &requests)
+
+	client := Client{UserName: userName, Token: token}
+	opts := &SyncOptions{Aggregation: AggregateMax, From: "20180917", DryRun: true}
+	diff, err := client.Graph(graphID).Sync(context.Background(), testSource, opts)
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	if len(diff.Update) != 1 || diff.Update[0].After.Quantity != "3" || len(diff.Delete) != 0 {
+		t.Errorf("got: %v\nwant: 1 update to 3 and no delete", diff)
+	}
+	if writes := writeRequests(requests); len(writes) != 0 {
+		t.Errorf("got: %v\nwant: no writes", writes)
+	}
+}
+
+func TestAggregatePixels(t *testing.T) {
+	pixels := []PixelValue{
+		{Date: "20180916", Quantity: "1.5"},
+		{Date: "20180915", Quantity: "2", OptionalData: "a"},
+		{Date: "20180915", Quantity: "4"},
+		{Date: "20180915", Quantity: "3"},
+	}
+	params := []struct {
+		aggregation string
+		expect      []PixelValue
+	}{
+		{aggregation: AggregateSum, expect: []PixelValue{{Date: "20180915", Quantity: "9", OptionalData: "a"}, {Date: "20180916", Quantity: "1.5"}}},
+		{aggregation: AggregateMax, expect: []PixelValue{{Date: "20180915", Quantity: "4", OptionalData: "a"}, {Date: "20180916", Quantity: "1.5"}}},
+		{aggregation: AggregateLast, expect: []PixelValue{{Date: "20180915", Quantity: "3", OptionalData: "a"}, {Date: "20180916", Quantity: "1.5"}}},
+		{aggregation: AggregateCount, expect: []PixelValue{{Date: "20180915", Quantity: "3", OptionalData: "a"}, {Date: "20180916", Quantity: "1"}}},
+	}
+
+	for _, p := range params {
+		actual, err := aggregatePixels(pixels, p.aggregation, TypeFloat)
+		if err != nil {
+			t.Errorf("%s: got: %v\nwant: nil", p.aggregation, err)
+		}
+		if reflect.DeepEqual(actual, p.expect) == false {
+			t.Errorf("%s: got: %v\nwant: %v", p.aggregation, actual, p.expect)
+		}
+	}
+
+	if _, err := aggregatePixels(pixels, "median", TypeFloat); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+}
+
+func TestGraphSyncReadsOnlyTheRange(t *testing.T) {
+	var requests, queries []string
+	mock := newExportMock(map[string]string{"20180915": "5", "20180916": "3"}, &requests)
+	clientMock = &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		if req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/pixels") {
+			queries = append(queries, req.URL.RawQuery)
+		}
+		return mock.handler(req)
+	}}
+
+	client := Client{UserName: userName, Token: token}
+	opts := &SyncOptions{From: "20170101", DryRun: true}
+	if _, err := client.Graph(graphID).Sync(context.Background(), testSource, opts); err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := []string{"from=20170101&to=20171231", "from=20180101&to=20180917"}
+	if reflect.DeepEqual(queries, expect) == false {
+		t.Errorf("got: %v\nwant: %v", queries, expect)
+	}
+	for _, r := range requests {
+		if strings.HasSuffix(r, "/stats") {
+			t.Errorf("got: %s\nwant: no stats request", r)
+		}
+	}
+}
+
+func TestAggregatePixelsNotInt(t *testing.T) {
+	pixels := []PixelValue{
+		{Date: "20180915", Quantity: "1.5"},
+		{Date: "20180915", Quantity: "2"},
+	}
+	if _, err := aggregatePixels(pixels, AggregateSum, TypeInt); err == nil {
+		t.Errorf("got: nil\nwant: error")
+	}
+
+	actual, err := aggregatePixels(append(pixels, PixelValue{Date: "20180915", Quantity: "0.5"}), AggregateSum, TypeInt)
+	if err != nil || actual[0].Quantity != "4" {
+		t.Errorf("got: %v, %v\nwant: 4", actual, err)
+	}
+}
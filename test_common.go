@@ -10,19 +10,31 @@ import (
 
 const (
 	userName = "user"
-	token    = "token"
+	token    = "secret-token"
 	graphID  = "graph-id"
 )
 
 type httpClientMock struct {
 	statusCode int
 	body       []byte
+	header     http.Header
+	handler    func(req *http.Request) (int, []byte)
+	err        error
 }
 
 func (c *httpClientMock) do(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
 	resp := &http.Response{}
 	resp.StatusCode = c.statusCode
+	resp.Header = c.header
 	resp.Body = ioutil.NopCloser(bytes.NewReader(c.body))
+	if c.handler != nil {
+		statusCode, body := c.handler(req)
+		resp.StatusCode = statusCode
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
 	return resp, nil
 }
 
@@ -44,7 +56,7 @@ func testSuccess(t *testing.T, actual *Result, err error) {
 		Message:   "Success.",
 		IsSuccess: true,
 	}
-	if *actual != *expect {
+	if actual.Message != expect.Message || actual.IsSuccess != expect.IsSuccess {
 		t.Errorf("got: %v\nwant: %v", actual, expect)
 	}
 }
@@ -65,7 +77,7 @@ func testAPIFailedResult(t *testing.T, result *Result, err error) {
 		Message:   "failed.",
 		IsSuccess: false,
 	}
-	if *result != *expect {
+	if result.Message != expect.Message || result.IsSuccess != expect.IsSuccess {
 		t.Errorf("got: %v\nwant: %v", result, expect)
 	}
 }
@@ -0,0 +1,181 @@
+package pixela
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TransportOptions configures how a Client sends requests to the Pixela API.
+// A zero duration means no timeout, as in net/http.
+type TransportOptions struct {
+	// MaxResponseSize is the maximum size of a response body in bytes.
+	// A larger response fails with ErrResponseTooLarge. If it is 0 or less, the size is not limited.
+	MaxResponseSize int64
+
+	// Timeout is the time limit of a request, including redirects and reading the response body.
+	Timeout time.Duration
+	// DialTimeout is the time limit to connect to the server.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout is the time limit of the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout is the time limit to wait for the response header after the request is written.
+	ResponseHeaderTimeout time.Duration
+	// IdleConnTimeout is how long an idle connection is kept for reuse.
+	IdleConnTimeout time.Duration
+
+	// MaxRedirects is the maximum number of redirects to follow. If it is 0, redirects are not followed
+	// and the redirect response is returned as it is.
+	// X-USER-TOKEN is never forwarded to another host or from https to http.
+	MaxRedirects int
+
+	// RootCAs is the set of root certificate authorities to verify the server. If nil, the system pool is used.
+	RootCAs *x509.CertPool
+	// MinTLSVersion is the minimum TLS version, such as tls.VersionTLS12. If it is 0, the default of crypto/tls is used.
+	MinTLSVersion uint16
+}
+
+// DefaultTransportOptions is the TransportOptions used unless Client.ConfigureTransport is called.
+var DefaultTransportOptions = TransportOptions{
+	MaxResponseSize:       10 << 20,
+	Timeout:               time.Minute,
+	DialTimeout:           10 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: 30 * time.Second,
+	IdleConnTimeout:       90 * time.Second,
+	MaxRedirects:          10,
+	MinTLSVersion:         tls.VersionTLS12,
+}
+
+// ErrResponseTooLarge is returned when a response body exceeds TransportOptions.MaxResponseSize.
+var ErrResponseTooLarge = errors.New("response body is too large")
+
+// maxDrainSize is how much of an unread response body is discarded to reuse the connection.
+// A longer body is left unread and its connection is closed instead.
+const maxDrainSize = 4 << 10
+
+// transport is the HTTP client of a Client and the limit of its response bodies.
+type transport struct {
+	client          *http.Client
+	maxResponseSize int64
+}
+
+// defaultTransport sends the requests of the resources not derived from a Client.
+var defaultTransport = newTransport(DefaultTransportOptions)
+
+// newTransport returns a transport that keeps the connections to the Pixela API alive for reuse.
+// All requests go to the same host, so as many idle connections are kept per host as in total.
+func newTransport(opts TransportOptions) *transport {
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   opts.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			RootCAs:    opts.RootCAs,
+			MinVersion: opts.MinTLSVersion,
+		},
+	}
+	return &transport{
+		client: &http.Client{
+			Transport:     t,
+			Timeout:       opts.Timeout,
+			CheckRedirect: checkRedirect(opts.MaxRedirects),
+		},
+		maxResponseSize: opts.MaxResponseSize,
+	}
+}
+
+// checkRedirect follows up to maxRedirects redirects and drops X-USER-TOKEN
+// when a redirect leaves the host of the original request or downgrades from https.
+func checkRedirect(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects <= 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return errors.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		original := via[0].URL
+		if req.URL.Host != original.Host || (original.Scheme == "https" && req.URL.Scheme != "https") {
+			req.Header.Del(userToken)
+		}
+		return nil
+	}
+}
+
+// ConfigureTransport replaces the HTTP client of the Client with the one configured by opts.
+// The requests in flight complete with the previous one.
+func (c *Client) ConfigureTransport(opts TransportOptions) {
+	t := newTransport(opts)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.transport != nil {
+		c.transport.client.Transport.(*http.Transport).CloseIdleConnections()
+	}
+	c.transport = t
+}
+
+// httpTransport returns the transport of c, which is created at first and shared by all requests of c.
+// If c is nil, defaultTransport is returned.
+func (c *Client) httpTransport() *transport {
+	if c == nil {
+		return defaultTransport
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.transport == nil {
+		c.transport = newTransport(DefaultTransportOptions)
+	}
+	return c.transport
+}
+
+// limit returns the body of the response limited to the maximum response size of t.
+func (t *transport) limit(resp *http.Response) (io.ReadCloser, error) {
+	if t.maxResponseSize <= 0 {
+		return resp.Body, nil
+	}
+	if resp.ContentLength > t.maxResponseSize {
+		return nil, errors.Wrapf(ErrResponseTooLarge, "failed to read response body")
+	}
+	return &limitedReader{ReadCloser: resp.Body, n: t.maxResponseSize}, nil
+}
+
+// limitedReader reads at most n bytes, and fails with ErrResponseTooLarge if the body has more.
+type limitedReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var probe [1]byte
+		if n, err := l.ReadCloser.Read(probe[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		} else if err != nil {
+			return 0, err
+		}
+		return 0, nil
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.ReadCloser.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
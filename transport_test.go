@@ -0,0 +1,171 @@
+package pixela
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// withoutClientMock sends the requests to the real servers until the returned function restores clientMock.
+func withoutClientMock() func() {
+	mock := clientMock
+	clientMock = nil
+	return func() { clientMock = mock }
+}
+
+func newTransportTestClient(opts TransportOptions) *Client {
+	client := NewClient(userName, token)
+	client.ConfigureTransport(opts)
+	return client
+}
+
+func TestTransportMaxResponseSize(t *testing.T) {
+	defer withoutClientMock()()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := strings.Repeat("a", 16)
+		if r.URL.Path == "/chunked" {
+			w.Write([]byte(body[:8]))
+			w.(http.Flusher).Flush()
+			body = body[8:]
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	opts := DefaultTransportOptions
+	opts.MaxResponseSize = 10
+	client := newTransportTestClient(opts)
+
+	for _, path := range []string{"/length", "/chunked"} {
+		_, err := client.doRequest(&requestParameter{Method: http.MethodGet, URL: server.URL + path})
+		if errors.Cause(err) != ErrResponseTooLarge {
+			t.Errorf("%s got: %v\nwant: %v", path, err, ErrResponseTooLarge)
+		}
+	}
+
+	opts.MaxResponseSize = 16
+	client.ConfigureTransport(opts)
+	b, err := client.doRequest(&requestParameter{Method: http.MethodGet, URL: server.URL + "/chunked"})
+	if err != nil || len(b) != 16 {
+		t.Errorf("got: %s, %v\nwant: 16 bytes", b, err)
+	}
+}
+
+func TestTransportTimeout(t *testing.T) {
+	defer withoutClientMock()()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	opts := DefaultTransportOptions
+	opts.Timeout = 50 * time.Millisecond
+	client := newTransportTestClient(opts)
+	if _, err := client.doRequest(&requestParameter{Method: http.MethodGet, URL: server.URL}); err == nil {
+		t.Errorf("got: nil\nwant: timeout of the request")
+	}
+
+	opts.Timeout = 0
+	opts.ResponseHeaderTimeout = 50 * time.Millisecond
+	client.ConfigureTransport(opts)
+	if _, err := client.doRequest(&requestParameter{Method: http.MethodGet, URL: server.URL}); err == nil {
+		t.Errorf("got: nil\nwant: timeout of the response header")
+	}
+}
+
+func TestTransportRedirect(t *testing.T) {
+	defer withoutClientMock()()
+
+	var forwarded []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = append(forwarded, r.Header.Get(userToken))
+		w.Write([]byte("other"))
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		case "/other":
+			http.Redirect(w, r, other.URL, http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			forwarded = append(forwarded, r.Header.Get(userToken))
+			w.Write([]byte("target"))
+		}
+	}))
+	defer server.Close()
+
+	client := newTransportTestClient(DefaultTransportOptions)
+	header := map[string]string{userToken: token}
+	for _, path := range []string{"/same", "/other"} {
+		if _, err := client.doRequest(&requestParameter{Method: http.MethodGet, URL: server.URL + path, Header: header}); err != nil {
+			t.Fatalf("got: %v\nwant: nil", err)
+		}
+	}
+	expect := []string{token, ""}
+	if len(forwarded) != 2 || forwarded[0] != expect[0] || forwarded[1] != expect[1] {
+		t.Errorf("got: %q\nwant: %q", forwarded, expect)
+	}
+
+	if _, err := client.doRequest(&requestParameter{Method: http.MethodGet, URL: server.URL + "/loop"}); err == nil {
+		t.Errorf("got: nil\nwant: too many redirects")
+	}
+
+	opts := DefaultTransportOptions
+	opts.MaxRedirects = 0
+	client.ConfigureTransport(opts)
+	b, err := client.doRequest(&requestParameter{Method: http.MethodGet, URL: server.URL + "/other", Header: header})
+	if err != nil || strings.Contains(string(b), "Found") == false {
+		t.Errorf("got: %s, %v\nwant: the redirect response", b, err)
+	}
+	if len(forwarded) != 2 {
+		t.Errorf("got: %q\nwant: no more requests", forwarded)
+	}
+}
+
+func TestTransportTLS(t *testing.T) {
+	defer withoutClientMock()()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	param := &requestParameter{Method: http.MethodGet, URL: server.URL}
+
+	client := newTransportTestClient(DefaultTransportOptions)
+	if _, err := client.doRequest(param); err == nil {
+		t.Errorf("got: nil\nwant: unknown certificate authority")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	opts := DefaultTransportOptions
+	opts.RootCAs = pool
+	client.ConfigureTransport(opts)
+	if b, err := client.doRequest(param); err != nil || string(b) != "ok" {
+		t.Errorf("got: %s, %v\nwant: ok", b, err)
+	}
+
+	opts.MinTLSVersion = tls.VersionTLS13
+	client.ConfigureTransport(opts)
+	if _, err := client.doRequest(param); err == nil {
+		t.Errorf("got: nil\nwant: protocol version not supported")
+	}
+}
@@ -11,15 +11,21 @@ import (
 type user struct {
 	UserName string
 	Token    string
+
+	client *Client
 }
 
 func (u *user) Create(agreeTermsOfService, notMinor bool, thanksCode string) (*Result, error) {
+	if err := validate(ValidateUserName(u.UserName), ValidateToken(u.Token)); err != nil {
+		return &Result{}, err
+	}
+
 	param, err := u.createCreateRequestParameter(agreeTermsOfService, notMinor, thanksCode)
 	if err != nil {
 		return &Result{}, errors.Wrapf(err, "failed to create user create parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	return u.client.doRequestAndParseResponse(param)
 }
 
 func (u *user) createCreateRequestParameter(agreeTermsOfService, notMinor bool, thanksCode string) (*requestParameter, error) {
@@ -36,10 +42,11 @@ func (u *user) createCreateRequestParameter(agreeTermsOfService, notMinor bool,
 	}
 
 	return &requestParameter{
-		Method: http.MethodPost,
-		URL:    APIBaseURL + "/users",
-		Header: map[string]string{},
-		Body:   b,
+		Operation: "Client.CreateUser",
+		Method:    http.MethodPost,
+		URL:       APIBaseURL + "/users",
+		Header:    map[string]string{},
+		Body:      b,
 	}, nil
 }
 
@@ -59,12 +66,24 @@ func boolToString(b bool) string {
 }
 
 func (u *user) Update(newToken, thanksCode string) (*Result, error) {
+	if err := validate(ValidateUserName(u.UserName), validateNewToken(newToken)); err != nil {
+		return &Result{}, err
+	}
+
 	param, err := u.createUpdateRequestParameter(newToken, thanksCode)
 	if err != nil {
 		return &Result{}, errors.Wrapf(err, "failed to create user update parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	return u.client.doRequestAndParseResponse(param)
+}
+
+// validateNewToken validates the new token as ValidateToken does, and reports it as the newToken field.
+func validateNewToken(newToken string) error {
+	if err, ok := ValidateToken(newToken).(*ValidationError); ok {
+		return &ValidationError{Field: "newToken", Message: err.Message}
+	}
+	return nil
 }
 
 func (u *user) createUpdateRequestParameter(newToken, thanksCode string) (*requestParameter, error) {
@@ -78,10 +97,11 @@ func (u *user) createUpdateRequestParameter(newToken, thanksCode string) (*reque
 	}
 
 	return &requestParameter{
-		Method: http.MethodPut,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s", u.UserName),
-		Header: map[string]string{userToken: u.Token},
-		Body:   b,
+		Operation: "Client.UpdateUser",
+		Method:    http.MethodPut,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s", u.UserName),
+		Header:    map[string]string{userToken: u.Token},
+		Body:      b,
 	}, nil
 }
 
@@ -96,14 +116,15 @@ func (u *user) Delete() (*Result, error) {
 		return &Result{}, errors.Wrapf(err, "failed to create user delete parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	return u.client.doRequestAndParseResponse(param)
 }
 
 func (u *user) createDeleteRequestParameter() (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodDelete,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s", u.UserName),
-		Header: map[string]string{userToken: u.Token},
-		Body:   []byte{},
+		Operation: "Client.DeleteUser",
+		Method:    http.MethodDelete,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s", u.UserName),
+		Header:    map[string]string{userToken: u.Token},
+		Body:      []byte{},
 	}, nil
 }
@@ -0,0 +1,94 @@
+package pixela
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	userNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{1,32}$`)
+	graphIDPattern  = regexp.MustCompile(`^[a-z][a-z0-9-]{1,16}$`)
+)
+
+// ValidationError is an error of a field that does not meet the rule of Pixela.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors is the errors of all fields that do not meet the rules of Pixela.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "invalid parameter: " + strings.Join(messages, ", ")
+}
+
+// ValidateUserName validates the user name.
+// It must start with a lowercase letter and consist of 2 to 33 lowercase letters, digits and "-".
+func ValidateUserName(userName string) error {
+	if userNamePattern.MatchString(userName) == false {
+		return &ValidationError{Field: "username", Message: "must match " + userNamePattern.String()}
+	}
+	return nil
+}
+
+// ValidateGraphID validates the graph ID.
+// It must start with a lowercase letter and consist of 2 to 17 lowercase letters, digits and "-".
+func ValidateGraphID(graphID string) error {
+	if graphIDPattern.MatchString(graphID) == false {
+		return &ValidationError{Field: "id", Message: "must match " + graphIDPattern.String()}
+	}
+	return nil
+}
+
+// ValidateToken validates the token.
+// It must consist of 8 to 128 printable ASCII characters.
+func ValidateToken(token string) error {
+	if len(token) < minTokenLength || len(token) > maxTokenLength {
+		return &ValidationError{Field: "token", Message: "must be between 8 and 128 characters"}
+	}
+	for _, r := range token {
+		if r < ' ' || r > '~' {
+			return &ValidationError{Field: "token", Message: "must consist of printable ASCII characters"}
+		}
+	}
+	return nil
+}
+
+// ValidateDate validates the date.
+// It must be a valid date in yyyyMMdd format.
+func ValidateDate(date string) error {
+	if _, err := time.Parse(dateFormat, date); err != nil || len(date) != len(dateFormat) {
+		return &ValidationError{Field: "date", Message: "must be a valid date in yyyyMMdd format"}
+	}
+	return nil
+}
+
+// validate runs the validators and returns ValidationErrors if any of them fails.
+// An error other than *ValidationError is returned as it is.
+func validate(errs ...error) error {
+	var validationErrors ValidationErrors
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		validationError, ok := err.(*ValidationError)
+		if ok == false {
+			return err
+		}
+		validationErrors = append(validationErrors, validationError)
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package pixela
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateUserName(t *testing.T) {
+	for _, s := range []string{"ab", "user", "a-b-1", "a" + strings.Repeat("b", 32)} {
+		if err := ValidateUserName(s); err != nil {
+			t.Errorf("%s: got: %v\nwant: nil", s, err)
+		}
+	}
+	for _, s := range []string{"", "a", "1user", "User", "user_name", "a" + strings.Repeat("b", 33)} {
+		if err := ValidateUserName(s); err == nil {
+			t.Errorf("%s: got: nil\nwant: error", s)
+		}
+	}
+}
+
+func TestValidateGraphID(t *testing.T) {
+	for _, s := range []string{"ab", "graph-id", "a" + strings.Repeat("b", 16)} {
+		if err := ValidateGraphID(s); err != nil {
+			t.Errorf("%s: got: %v\nwant: nil", s, err)
+		}
+	}
+	for _, s := range []string{"", "a", "-graph", "Graph", "a" + strings.Repeat("b", 17)} {
+		if err := ValidateGraphID(s); err == nil {
+			t.Errorf("%s: got: nil\nwant: error", s)
+		}
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	for _, s := range []string{"12345678", "secret token!", strings.Repeat("a", 128)} {
+		if err := ValidateToken(s); err != nil {
+			t.Errorf("%s: got: %v\nwant: nil", s, err)
+		}
+	}
+	for _, s := range []string{"1234567", strings.Repeat("a", 129), "secret\ttoken", "シークレットトークン"} {
+		if err := ValidateToken(s); err == nil {
+			t.Errorf("%s: got: nil\nwant: error", s)
+		}
+	}
+}
+
+func TestValidateDate(t *testing.T) {
+	for _, s := range []string{"20180915", "20200229"} {
+		if err := ValidateDate(s); err != nil {
+			t.Errorf("%s: got: %v\nwant: nil", s, err)
+		}
+	}
+	for _, s := range []string{"", "2018-09-15", "20180931", "20190229", "201809150"} {
+		if err := ValidateDate(s); err == nil {
+			t.Errorf("%s: got: nil\nwant: error", s)
+		}
+	}
+}
+
+func TestUserCreateInvalid(t *testing.T) {
+	var requests []string
+	clientMock = &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		requests = append(requests, req.URL.String())
+		return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+	}}
+
+	client := Client{UserName: "1user", Token: "short"}
+	_, err := client.CreateUser(true, true, "")
+
+	errs, ok := err.(ValidationErrors)
+	if ok == false {
+		t.Fatalf("got: %v\nwant: ValidationErrors", err)
+	}
+	if len(errs) != 2 || errs[0].Field != "username" || errs[1].Field != "token" {
+		t.Errorf("got: %v\nwant: username and token", err)
+	}
+	if strings.Contains(err.Error(), "short") {
+		t.Errorf("error contains token: %s", err.Error())
+	}
+	if len(requests) != 0 {
+		t.Errorf("invalid parameter sent: %v", requests)
+	}
+}
+
+func TestGraphCreateInvalid(t *testing.T) {
+	client := Client{UserName: userName, Token: token}
+	_, err := client.Graph("Graph_ID").Create("name", "times", TypeInt, ColorShibafu, "UTC", SelfSufficientNone, false, false)
+	if _, ok := err.(ValidationErrors); ok == false {
+		t.Errorf("got: %v\nwant: ValidationErrors", err)
+	}
+}
+
+func TestGraphCreateInvalidUser(t *testing.T) {
+	client := Client{UserName: "1user", Token: token}
+	_, err := client.Graph(graphID).Create("name", "times", TypeInt, ColorShibafu, "UTC", SelfSufficientNone, false, false)
+
+	errs, ok := err.(ValidationErrors)
+	if ok == false {
+		t.Fatalf("got: %v\nwant: ValidationErrors", err)
+	}
+	if len(errs) != 1 || errs[0].Field != "username" {
+		t.Errorf("got: %v\nwant: username", err)
+	}
+}
+
+func TestUserUpdateInvalid(t *testing.T) {
+	var requests []string
+	clientMock = &httpClientMock{handler: func(req *http.Request) (int, []byte) {
+		requests = append(requests, req.URL.String())
+		return http.StatusOK, []byte(`{"message":"Success.","isSuccess":true}`)
+	}}
+
+	client := Client{UserName: userName, Token: token}
+	_, err := client.UpdateUser("short", "")
+
+	errs, ok := err.(ValidationErrors)
+	if ok == false {
+		t.Fatalf("got: %v\nwant: ValidationErrors", err)
+	}
+	if len(errs) != 1 || errs[0].Field != "newToken" {
+		t.Errorf("got: %v\nwant: newToken", err)
+	}
+	if strings.Contains(err.Error(), "short") {
+		t.Errorf("error contains token: %s", err.Error())
+	}
+	if len(requests) != 0 || client.Token != token {
+		t.Errorf("invalid parameter sent: %v, token: %s", requests, client.Token)
+	}
+}
+
+func TestPixelCreateInvalid(t *testing.T) {
+	client := Client{UserName: userName, Token: token}
+	_, err := client.Pixel(graphID).Create("2018-09-15", "5", "")
+	if _, ok := err.(ValidationErrors); ok == false {
+		t.Errorf("got: %v\nwant: ValidationErrors", err)
+	}
+}
+
+func TestPixelCreateInvalidGraph(t *testing.T) {
+	client := Client{UserName: "1user", Token: token}
+	_, err := client.Pixel("Graph_ID").Create("20180915", "5", "")
+
+	errs, ok := err.(ValidationErrors)
+	if ok == false {
+		t.Fatalf("got: %v\nwant: ValidationErrors", err)
+	}
+	if len(errs) != 2 || errs[0].Field != "username" || errs[1].Field != "id" {
+		t.Errorf("got: %v\nwant: username and id", err)
+	}
+}
+
+func TestValidateOtherError(t *testing.T) {
+	other := errors.New("other")
+	if err := validate(ValidateDate("20180915"), other); err != other {
+		t.Errorf("got: %v\nwant: %v", err, other)
+	}
+}
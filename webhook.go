@@ -9,9 +9,14 @@ import (
 )
 
 // A Webhook manages communication with the Pixela webhook API.
+// If it is returned by Client.Webhook, it uses the current credentials of the Client
+// instead of UserName and Token, so that a token rotated by Client.UpdateUser reaches it.
 type Webhook struct {
 	UserName string
 	Token    string
+
+	client *Client
+	state  *credentialState
 }
 
 // Create create a new Webhook.
@@ -21,16 +26,13 @@ func (w *Webhook) Create(graphID, selfSufficient string) (*WebhookCreateResult,
 		return &WebhookCreateResult{}, errors.Wrapf(err, "failed to create webhook create parameter")
 	}
 
-	b, err := doRequest(param)
-	if err != nil {
-		return &WebhookCreateResult{}, errors.Wrapf(err, "failed to do request")
-	}
-
 	var createResult WebhookCreateResult
-	if err := json.Unmarshal(b, &createResult); err != nil {
-		return &WebhookCreateResult{}, errors.Wrapf(err, "failed to unmarshal json")
+	statusCode, err := w.client.doRequestAndDecode(param, &createResult)
+	if err != nil {
+		return &WebhookCreateResult{}, err
 	}
 
+	createResult.StatusCode = statusCode
 	return &createResult, nil
 }
 
@@ -48,10 +50,11 @@ func (w *Webhook) createCreateRequestParameter(graphID, selfSufficient string) (
 	}
 
 	return &requestParameter{
-		Method: http.MethodPost,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/webhooks", w.UserName),
-		Header: map[string]string{userToken: w.Token},
-		Body:   b,
+		Operation: "Webhook.Create",
+		Method:    http.MethodPost,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/webhooks", w.userName()),
+		Header:    map[string]string{userToken: w.token()},
+		Body:      b,
 	}, nil
 }
 
@@ -67,16 +70,13 @@ func (w *Webhook) GetAll() (*WebhookDefinitions, error) {
 		return &WebhookDefinitions{}, errors.Wrapf(err, "failed to create get all webhooks parameter")
 	}
 
-	b, err := doRequest(param)
-	if err != nil {
-		return &WebhookDefinitions{}, errors.Wrapf(err, "failed to do request")
-	}
-
 	var definitions WebhookDefinitions
-	if err := json.Unmarshal(b, &definitions); err != nil {
-		return &WebhookDefinitions{}, errors.Wrapf(err, "failed to unmarshal json")
+	statusCode, err := w.client.doRequestAndDecode(param, &definitions)
+	if err != nil {
+		return &WebhookDefinitions{}, err
 	}
 
+	definitions.StatusCode = statusCode
 	definitions.IsSuccess = definitions.Message == ""
 	return &definitions, nil
 }
@@ -96,10 +96,11 @@ type WebhookDefinition struct {
 
 func (w *Webhook) createGetAllRequestParameter() (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodGet,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/webhooks", w.UserName),
-		Header: map[string]string{userToken: w.Token},
-		Body:   []byte{},
+		Operation: "Webhook.GetAll",
+		Method:    http.MethodGet,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/webhooks", w.userName()),
+		Header:    map[string]string{userToken: w.token()},
+		Body:      []byte{},
 	}, nil
 }
 
@@ -110,15 +111,16 @@ func (w *Webhook) Delete(webhookHash string) (*Result, error) {
 		return &Result{}, errors.Wrapf(err, "failed to create webhook delete parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	return w.client.doRequestAndParseResponse(param)
 }
 
 func (w *Webhook) createDeleteRequestParameter(webhookHash string) (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodDelete,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/webhooks/%s", w.UserName, webhookHash),
-		Header: map[string]string{userToken: w.Token},
-		Body:   []byte{},
+		Operation: "Webhook.Delete",
+		Method:    http.MethodDelete,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/webhooks/%s", w.userName(), webhookHash),
+		Header:    map[string]string{userToken: w.token()},
+		Body:      []byte{},
 	}, nil
 }
 
@@ -130,14 +132,28 @@ func (w *Webhook) Invoke(webhookHash string) (*Result, error) {
 		return &Result{}, errors.Wrapf(err, "failed to create webhook invoke parameter")
 	}
 
-	return doRequestAndParseResponse(param)
+	result, err := w.client.doRequestAndParseResponse(param)
+	if err == nil && result.IsSuccess {
+		// The graph of the webhook is unknown here.
+		w.client.invalidateUser(w.userName())
+	}
+	return result, err
 }
 
 func (w *Webhook) createInvokeRequestParameter(webhookHash string) (*requestParameter, error) {
 	return &requestParameter{
-		Method: http.MethodPost,
-		URL:    fmt.Sprintf(APIBaseURL+"/users/%s/webhooks/%s", w.UserName, webhookHash),
-		Header: map[string]string{contentLength: "0"},
-		Body:   []byte{},
+		Operation: "Webhook.Invoke",
+		Method:    http.MethodPost,
+		URL:       fmt.Sprintf(APIBaseURL+"/users/%s/webhooks/%s", w.userName(), webhookHash),
+		Header:    map[string]string{contentLength: "0"},
+		Body:      []byte{},
 	}, nil
 }
+
+func (w *Webhook) userName() string {
+	return w.state.userNameOr(w.UserName)
+}
+
+func (w *Webhook) token() string {
+	return w.state.tokenOr(w.Token)
+}
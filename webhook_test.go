@@ -10,7 +10,7 @@ import (
 
 func TestCreateWebhookCreateRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Webhook().createCreateRequestParameter(graphID, SelfSufficientIncrement)
+	param, err := client.webhook().createCreateRequestParameter(graphID, SelfSufficientIncrement)
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -48,7 +48,7 @@ func TestWebhookCreate(t *testing.T) {
 
 	expect := &WebhookCreateResult{
 		WebhookHash: "webhook-hash",
-		Result:      Result{Message: "Success.", IsSuccess: true},
+		Result:      Result{Message: "Success.", IsSuccess: true, StatusCode: http.StatusOK},
 	}
 	if *result != *expect {
 		t.Errorf("got: %v\nwant: %v", result, expect)
@@ -75,7 +75,7 @@ func TestWebhookCreateError(t *testing.T) {
 
 func TestCreateWebhookGetAllRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Webhook().createGetAllRequestParameter()
+	param, err := client.webhook().createGetAllRequestParameter()
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -117,7 +117,7 @@ func TestWebhookGetAll(t *testing.T) {
 				Type:        "increment",
 			},
 		},
-		Result: Result{IsSuccess: true},
+		Result: Result{IsSuccess: true, StatusCode: http.StatusOK},
 	}
 	if reflect.DeepEqual(definitions, expect) == false {
 		t.Errorf("got: %v\nwant: %v", definitions, expect)
@@ -144,7 +144,7 @@ func TestWebhookGetAllError(t *testing.T) {
 
 func TestCreateWebhookDeleteRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Webhook().createDeleteRequestParameter("webhook-hash")
+	param, err := client.webhook().createDeleteRequestParameter("webhook-hash")
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
@@ -187,7 +187,7 @@ func TestWebhookDeleteFail(t *testing.T) {
 
 func TestCreateWebhookInvokeRequestParameter(t *testing.T) {
 	client := Client{UserName: userName, Token: token}
-	param, err := client.Webhook().createInvokeRequestParameter("webhook-hash")
+	param, err := client.webhook().createInvokeRequestParameter("webhook-hash")
 	if err != nil {
 		t.Errorf("got: %v\nwant: nil", err)
 	}
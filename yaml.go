@@ -0,0 +1,372 @@
+package pixela
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// yamlLine is a line of a YAML document without its indentation and comment.
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// yamlParser parses the subset of YAML a Manifest needs: block mappings and sequences, flow sequences and mappings
+// of scalars, and plain, single-quoted and double-quoted scalars. Anchors, aliases, tags, multi-line scalars
+// and multiple documents are not supported.
+//
+// Plain scalars are strings, except true, false and null, because every field of a Manifest is a string or a bool.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseYAML parses the YAML document into the maps, slices, strings, bools and nils it represents.
+func parseYAML(b []byte) (interface{}, error) {
+	lines, err := yamlLines(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	v, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, errors.Errorf("line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+	return v, nil
+}
+
+func yamlLines(b []byte) ([]yamlLine, error) {
+	var lines []yamlLine
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, errors.Errorf("line %d: tabs are not allowed in indentation", number)
+		}
+		text = strings.TrimSpace(stripYAMLComment(text))
+		if text == "" || (number == 1 && text == "---") {
+			continue
+		}
+		if text == "---" || text == "..." {
+			return nil, errors.Errorf("line %d: multiple documents are not supported", number)
+		}
+		lines = append(lines, yamlLine{number: number, indent: len(line) - len(strings.TrimLeft(line, " ")), text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read yaml")
+	}
+	return lines, nil
+}
+
+// stripYAMLComment removes the comment that starts with # at the start of s or after a space outside quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block parses the mapping or sequence whose entries are at indent.
+func (p *yamlParser) block(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) ([]interface{}, error) {
+	sequence := []interface{}{}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent || (l.indent == indent && isYAMLSequenceItem(l.text) == false) {
+			break
+		}
+		if l.indent > indent {
+			return nil, errors.Errorf("line %d: unexpected indentation", l.number)
+		}
+
+		item := strings.TrimLeft(l.text[1:], " ")
+		if item == "" {
+			p.pos++
+			v, err := p.nested(indent, false)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, v)
+			continue
+		}
+		if _, _, ok := splitYAMLEntry(item); ok || isYAMLSequenceItem(item) {
+			// The item is a block that starts on this line, such as "- id: graph-id".
+			p.lines[p.pos] = yamlLine{number: l.number, indent: indent + len(l.text) - len(item), text: item}
+			v, err := p.block(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, v)
+			continue
+		}
+		v, err := parseYAMLValue(item, l.number)
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, v)
+		p.pos++
+	}
+	return sequence, nil
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]interface{}, error) {
+	mapping := map[string]interface{}{}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent || isYAMLSequenceItem(l.text) {
+			return nil, errors.Errorf("line %d: unexpected indentation", l.number)
+		}
+
+		key, value, ok := splitYAMLEntry(l.text)
+		if ok == false {
+			return nil, errors.Errorf("line %d: mapping entry expected: %s", l.number, l.text)
+		}
+		if _, ok := mapping[key]; ok {
+			return nil, errors.Errorf("line %d: duplicate key: %s", l.number, key)
+		}
+		p.pos++
+
+		var v interface{}
+		var err error
+		if value == "" {
+			// A sequence may be at the same indentation as its key.
+			v, err = p.nested(indent, true)
+		} else {
+			v, err = parseYAMLValue(value, l.number)
+		}
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = v
+	}
+	return mapping, nil
+}
+
+// nested parses the block after a key or a sequence item without a value on its line, or returns nil if there is none.
+func (p *yamlParser) nested(indent int, sequenceAtIndent bool) (interface{}, error) {
+	if p.pos == len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent || (sequenceAtIndent && next.indent == indent && isYAMLSequenceItem(next.text)) {
+		return p.block(next.indent)
+	}
+	return nil, nil
+}
+
+// splitYAMLEntry splits "key: value" or "key:" into the key and the value.
+func splitYAMLEntry(text string) (string, string, bool) {
+	var key, rest string
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingYAMLQuote(text)
+		if end < 0 {
+			return "", "", false
+		}
+		unquoted, err := parseYAMLQuoted(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		key, rest = unquoted, text[end+1:]
+		if strings.HasPrefix(rest, ":") == false {
+			return "", "", false
+		}
+		rest = rest[1:]
+	} else {
+		i := strings.Index(text, ": ")
+		if i < 0 {
+			if strings.HasSuffix(text, ":") == false {
+				return "", "", false
+			}
+			i = len(text) - 1
+		}
+		key, rest = strings.TrimSpace(text[:i]), text[i+1:]
+		if key == "" || strings.ContainsAny(key[:1], "[{") {
+			return "", "", false
+		}
+	}
+	if rest != "" && rest[0] != ' ' {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(rest), true
+}
+
+// closingYAMLQuote returns the index of the quote that closes the quoted scalar at the start of s, or -1.
+func closingYAMLQuote(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func parseYAMLValue(s string, number int) (interface{}, error) {
+	switch s[0] {
+	case '[':
+		return parseYAMLFlow(s, ']', number)
+	case '{':
+		return parseYAMLFlow(s, '}', number)
+	case '|', '>':
+		return nil, errors.Errorf("line %d: multi-line scalars are not supported", number)
+	case '&', '*', '!':
+		return nil, errors.Errorf("line %d: anchors, aliases and tags are not supported", number)
+	}
+	return parseYAMLScalar(s, number)
+}
+
+// parseYAMLFlow parses a flow sequence or mapping of scalars on a single line.
+func parseYAMLFlow(s string, closing byte, number int) (interface{}, error) {
+	if s[len(s)-1] != closing {
+		return nil, errors.Errorf("line %d: flow collections must be on a single line", number)
+	}
+	items, err := splitYAMLFlow(s[1:len(s)-1], number)
+	if err != nil {
+		return nil, err
+	}
+
+	if closing == ']' {
+		sequence := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			v, err := parseYAMLScalar(item, number)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, v)
+		}
+		return sequence, nil
+	}
+
+	mapping := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		key, value, ok := splitYAMLEntry(item)
+		if ok == false {
+			return nil, errors.Errorf("line %d: mapping entry expected: %s", number, item)
+		}
+		v, err := parseYAMLScalar(value, number)
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = v
+	}
+	return mapping, nil
+}
+
+func splitYAMLFlow(s string, number int) ([]string, error) {
+	var items []string
+	start := 0
+	var quote byte
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			c := s[i]
+			switch {
+			case quote != 0:
+				if c == '\\' && quote == '"' {
+					i++
+				} else if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '"' || c == '\'':
+				quote = c
+				continue
+			case c == '[' || c == '{':
+				return nil, errors.Errorf("line %d: nested flow collections are not supported", number)
+			case c != ',':
+				continue
+			}
+		}
+		item := strings.TrimSpace(s[start:i])
+		start = i + 1
+		if item == "" {
+			if i == len(s) && len(items) == 0 {
+				break
+			}
+			return nil, errors.Errorf("line %d: empty flow collection item", number)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func parseYAMLScalar(s string, number int) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		if closingYAMLQuote(s) != len(s)-1 {
+			return nil, errors.Errorf("line %d: invalid quoted scalar: %s", number, s)
+		}
+		v, err := parseYAMLQuoted(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: invalid quoted scalar: %s", number, s)
+		}
+		return v, nil
+	}
+
+	switch s {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	return s, nil
+}
+
+// parseYAMLQuoted unquotes a quoted scalar. The escapes of double-quoted scalars are read as the ones of JSON.
+func parseYAMLQuoted(s string) (string, error) {
+	if s[0] == '\'' {
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+	var v string
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
@@ -0,0 +1,71 @@
+package pixela
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	s := `---
+graphs:
+  - id: graph-id # a comment
+    name: "commits # of the day"
+    unit: 'it''s'
+    purgeCacheURLs: [https://example.com/a, "https://example.com/b"]
+    isSecret: true
+    publishOptionalData: false
+    timezone: ~
+  -
+    id: empty
+    purgeCacheURLs: []
+webhooks:
+`
+	actual, err := parseYAML([]byte(s))
+	if err != nil {
+		t.Fatalf("got: %v\nwant: nil", err)
+	}
+
+	expect := map[string]interface{}{
+		"graphs": []interface{}{
+			map[string]interface{}{
+				"id":                  "graph-id",
+				"name":                "commits # of the day",
+				"unit":                "it's",
+				"purgeCacheURLs":      []interface{}{"https://example.com/a", "https://example.com/b"},
+				"isSecret":            true,
+				"publishOptionalData": false,
+				"timezone":            nil,
+			},
+			map[string]interface{}{
+				"id":             "empty",
+				"purgeCacheURLs": []interface{}{},
+			},
+		},
+		"webhooks": nil,
+	}
+	if reflect.DeepEqual(actual, expect) == false {
+		t.Errorf("got: %#v\nwant: %#v", actual, expect)
+	}
+}
+
+func TestParseYAMLFail(t *testing.T) {
+	params := []string{
+		"graphs:\n  - id: a\n   name: b\n",
+		"graphs:\n\t- id: a\n",
+		"id: a\nid: b\n",
+		"id: a\n- b\n",
+		"just a scalar\n",
+		"name: |\n  text\n",
+		"name: &anchor a\n",
+		"urls: [a, [b]]\n",
+		"urls: [a,\n  b]\n",
+		"name: \"unterminated\n",
+		"a: 1\n---\nb: 2\n",
+	}
+
+	for _, p := range params {
+		if v, err := parseYAML([]byte(p)); err == nil {
+			t.Errorf("%q: got: %v\nwant: error", p, v)
+		}
+	}
+}